@@ -0,0 +1,120 @@
+package browser
+
+import (
+	"context"
+	"slices"
+	"sync/atomic"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"github.com/rs/zerolog/log"
+)
+
+var resourceTypes = []network.ResourceType{
+	network.ResourceTypeDocument,
+	network.ResourceTypeStylesheet,
+	network.ResourceTypeImage,
+	network.ResourceTypeMedia,
+	network.ResourceTypeFont,
+	network.ResourceTypeScript,
+	network.ResourceTypeTextTrack,
+	network.ResourceTypeXHR,
+	network.ResourceTypeFetch,
+	network.ResourceTypePrefetch,
+	network.ResourceTypeEventSource,
+	network.ResourceTypeWebSocket,
+	network.ResourceTypeManifest,
+	network.ResourceTypeSignedExchange,
+	network.ResourceTypePing,
+	network.ResourceTypeCSPViolationReport,
+	network.ResourceTypePreflight,
+	network.ResourceTypeOther,
+}
+
+// IsResourceType reports whether name is a DevTools resource type such as
+// "Image" or "Font".
+func IsResourceType(name string) bool {
+	return slices.Contains(resourceTypes, network.ResourceType(name))
+}
+
+// Blocking lists the requests tabs should abort: every request of one of
+// ResourceTypes, and every request whose URL matches one of URLPatterns
+// (DevTools wildcards, '*' for any run of characters and '?' for one).
+type Blocking struct {
+	ResourceTypes []string
+	URLPatterns   []string
+}
+
+func (b Blocking) patterns() []*fetch.RequestPattern {
+	var patterns []*fetch.RequestPattern
+
+	for _, resourceType := range b.ResourceTypes {
+		patterns = append(patterns, &fetch.RequestPattern{
+			URLPattern:   "*",
+			ResourceType: network.ResourceType(resourceType),
+		})
+	}
+
+	for _, urlPattern := range b.URLPatterns {
+		patterns = append(patterns, &fetch.RequestPattern{URLPattern: urlPattern})
+	}
+
+	return patterns
+}
+
+// blockRequests makes the tab abort every request that matches patterns. The
+// fetch domain only pauses matching requests, so everything paused is failed.
+func blockRequests(tabCtx context.Context, patterns []*fetch.RequestPattern, stats *crawlStats) chromedp.Action {
+	chromedp.ListenTarget(tabCtx, func(ev any) {
+		paused, ok := ev.(*fetch.EventRequestPaused)
+
+		if !ok {
+			return
+		}
+
+		if stats != nil {
+			stats.blocked.Add(1)
+		}
+
+		go func() {
+			c := chromedp.FromContext(tabCtx)
+			ctx := cdp.WithExecutor(tabCtx, c.Target)
+
+			if err := fetch.FailRequest(paused.RequestID, network.ErrorReasonBlockedByClient).Do(ctx); err != nil && tabCtx.Err() == nil {
+				log.Debug().Err(err).Msgf("Failed to block request %s", paused.Request.URL)
+			}
+		}()
+	})
+
+	return fetch.Enable().WithPatterns(patterns)
+}
+
+type crawlStatsKey struct{}
+
+type crawlStats struct {
+	blocked atomic.Int64
+}
+
+// WithCrawlStats returns a context that counts the requests blocked in every
+// tab opened with it, see BlockedRequests.
+func WithCrawlStats(ctx context.Context) context.Context {
+	return context.WithValue(ctx, crawlStatsKey{}, &crawlStats{})
+}
+
+// BlockedRequests returns how many requests were blocked in tabs opened with
+// a context from WithCrawlStats.
+func BlockedRequests(ctx context.Context) int64 {
+	if stats := statsFromContext(ctx); stats != nil {
+		return stats.blocked.Load()
+	}
+
+	return 0
+}
+
+func statsFromContext(ctx context.Context) *crawlStats {
+	stats, _ := ctx.Value(crawlStatsKey{}).(*crawlStats)
+
+	return stats
+}
@@ -0,0 +1,292 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/chromedp"
+	"github.com/rs/zerolog/log"
+)
+
+var ErrPoolClosed = errors.New("browser pool is closed")
+
+// Options configures a Pool. When RemoteURL is set the pool connects to an
+// already running Chrome over its DevTools endpoint instead of launching local
+// processes, and ExecOptions are ignored.
+type Options struct {
+	Size        int
+	MaxTabs     int
+	ExecOptions []chromedp.ExecAllocatorOption
+	// RemoteURL accepts ws://host:port/devtools/browser/<id>, or ws://host:port
+	// and http://host:port, which are resolved through /json/version on every
+	// connect so a restarted remote browser is picked up automatically.
+	RemoteURL string
+	// UserAgent is applied to every tab of a remote browser, since it can't be
+	// passed as a launch flag.
+	UserAgent string
+	// HealthInterval is how often remote connections are checked. Broken ones
+	// are dropped and reconnected on the next Tab.
+	HealthInterval time.Duration
+	// Block lists requests every tab aborts, to skip assets crawls never read.
+	Block Blocking
+}
+
+// Pool keeps a bounded number of long-lived browsers and hands out tabs on
+// them, so crawls don't pay for a fresh Chromium process per page. A browser
+// that crashes or loses its connection is replaced the next time a tab is
+// requested.
+type Pool struct {
+	ctx        context.Context
+	cancel     context.CancelFunc
+	options    Options
+	tabActions []chromedp.Action
+	blocked    []*fetch.RequestPattern
+	tabs       chan struct{}
+	mu         sync.Mutex
+	browsers   []*instance
+}
+
+type instance struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	tabs   int
+}
+
+// NewPool creates a pool of up to options.Size browsers with at most
+// options.MaxTabs tabs open on each. Browsers are started lazily and all of
+// them are shut down when ctx is cancelled or Close is called.
+func NewPool(ctx context.Context, options Options) *Pool {
+	ctx, cancel := context.WithCancel(ctx)
+	p := &Pool{
+		ctx:      ctx,
+		cancel:   cancel,
+		options:  options,
+		tabs:     make(chan struct{}, options.Size*options.MaxTabs),
+		browsers: make([]*instance, options.Size),
+		blocked:  options.Block.patterns(),
+	}
+
+	if options.RemoteURL != "" {
+		if options.UserAgent != "" {
+			p.tabActions = append(p.tabActions, emulation.SetUserAgentOverride(options.UserAgent))
+		}
+
+		if options.HealthInterval > 0 {
+			go p.checkHealth()
+		}
+	}
+
+	return p
+}
+
+// Tab opens a new tab and returns a chromedp context for it. The tab is closed
+// by calling the returned cancel func, or when ctx is done. Tab blocks while
+// every browser already has its maximum number of tabs open.
+func (p *Pool) Tab(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	select {
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	case <-p.ctx.Done():
+		return nil, nil, ErrPoolClosed
+	case p.tabs <- struct{}{}:
+	}
+
+	inst, err := p.acquire()
+
+	if err != nil {
+		<-p.tabs
+		return nil, nil, err
+	}
+
+	tabCtx, tabCancel := chromedp.NewContext(inst.ctx)
+	stop := context.AfterFunc(ctx, tabCancel)
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			stop()
+			tabCancel()
+			p.release(inst)
+			<-p.tabs
+		})
+	}
+
+	actions := slices.Clone(p.tabActions)
+
+	if len(p.blocked) > 0 {
+		actions = append(actions, blockRequests(tabCtx, p.blocked, statsFromContext(ctx)))
+	}
+
+	if len(actions) > 0 {
+		if err := chromedp.Run(tabCtx, actions...); err != nil {
+			release()
+			return nil, nil, err
+		}
+	}
+
+	return tabCtx, release, nil
+}
+
+// Close shuts down every browser and waits for them to exit.
+func (p *Pool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, inst := range p.browsers {
+		if inst != nil {
+			inst.close()
+			p.browsers[i] = nil
+		}
+	}
+
+	p.cancel()
+}
+
+// acquire returns the least loaded live browser. Browsers that have crashed
+// are dropped, and a new one is launched into a free slot whenever every live
+// browser is already busy.
+func (p *Pool) acquire() (*instance, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.ctx.Err() != nil {
+		return nil, ErrPoolClosed
+	}
+
+	empty := -1
+	best := -1
+
+	for i, inst := range p.browsers {
+		if inst != nil && inst.ctx.Err() != nil {
+			log.Warn().Msgf("Browser %d exited, recycling it", i)
+			inst.close()
+			p.browsers[i] = nil
+		}
+
+		if p.browsers[i] == nil {
+			if empty == -1 {
+				empty = i
+			}
+
+			continue
+		}
+
+		if best == -1 || p.browsers[i].tabs < p.browsers[best].tabs {
+			best = i
+		}
+	}
+
+	if empty != -1 && (best == -1 || p.browsers[best].tabs > 0) {
+		inst, err := p.launch()
+
+		if err != nil && best == -1 {
+			return nil, err
+		} else if err != nil {
+			log.Error().Err(err).Msg("Failed to launch browser, reusing a busy one")
+		} else {
+			p.browsers[empty] = inst
+			best = empty
+		}
+	}
+
+	p.browsers[best].tabs++
+
+	return p.browsers[best], nil
+}
+
+func (p *Pool) release(inst *instance) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	inst.tabs--
+}
+
+func (p *Pool) launch() (*instance, error) {
+	var allocCtx context.Context
+	var allocCancel context.CancelFunc
+
+	if p.options.RemoteURL != "" {
+		allocCtx, allocCancel = chromedp.NewRemoteAllocator(p.ctx, p.options.RemoteURL)
+	} else {
+		allocCtx, allocCancel = chromedp.NewExecAllocator(p.ctx, p.options.ExecOptions...)
+	}
+
+	ctx, cancel := chromedp.NewContext(allocCtx)
+
+	// Running without actions starts the browser, or connects to it.
+	if err := chromedp.Run(ctx); err != nil {
+		cancel()
+		allocCancel()
+		return nil, err
+	}
+
+	return &instance{
+		ctx: ctx,
+		cancel: func() {
+			cancel()
+			allocCancel()
+		},
+	}, nil
+}
+
+// checkHealth periodically pings every remote connection and drops the ones
+// that don't answer, so the next Tab reconnects.
+func (p *Pool) checkHealth() {
+	ticker := time.NewTicker(p.options.HealthInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		p.mu.Lock()
+		browsers := slices.Clone(p.browsers)
+		p.mu.Unlock()
+
+		for i, inst := range browsers {
+			if inst == nil || inst.ctx.Err() != nil {
+				continue
+			}
+
+			if err := inst.ping(p.options.HealthInterval); err != nil {
+				log.Warn().Err(err).Msgf("Remote browser %d failed health check, reconnecting", i)
+				p.drop(i, inst)
+			}
+		}
+	}
+}
+
+func (p *Pool) drop(i int, inst *instance) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.browsers[i] == inst {
+		inst.close()
+		p.browsers[i] = nil
+	}
+}
+
+func (i *instance) ping(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(i.ctx, timeout)
+	defer cancel()
+
+	_, err := chromedp.Targets(ctx)
+
+	return err
+}
+
+func (i *instance) close() {
+	if err := chromedp.Cancel(i.ctx); err != nil && !errors.Is(err, context.Canceled) {
+		log.Error().Err(err).Msg("Failed to close browser")
+	}
+
+	i.cancel()
+}
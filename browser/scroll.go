@@ -0,0 +1,23 @@
+package browser
+
+import (
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// Scroll scrolls the page to the bottom the given number of times, pausing
+// after each so that sites which load more content on scroll can catch up.
+func Scroll(times int) chromedp.Tasks {
+	var tasks chromedp.Tasks
+
+	for range times {
+		tasks = append(tasks,
+			chromedp.WaitReady("body"),
+			chromedp.Evaluate(`window.scrollTo(0, document.body.scrollHeight);`, nil),
+			chromedp.Sleep(1*time.Second),
+		)
+	}
+
+	return append(tasks, chromedp.WaitReady("body"))
+}
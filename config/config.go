@@ -1,52 +1,208 @@
 package config
 
 import (
+	"bytes"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
 )
 
 const defaultUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36"
 
+const EnvConfigPath = "CELEVE_CONFIG"
+const envUserAgent = "CELEVE_USER_AGENT"
+const envEventStorePath = "CELEVE_EVENT_STORE_PATH"
+const envHTTPServerAddress = "CELEVE_HTTP_SERVER_ADDRESS"
+const envHTTPProxy = "CELEVE_HTTP_PROXY"
+const envJobInterval = "CELEVE_JOB_INTERVAL"
+const envEnableProcessorJob = "CELEVE_ENABLE_PROCESSOR_JOB"
+const envRemoteBrowserURL = "CELEVE_REMOTE_BROWSER_URL"
+
 type MeetupStrategyConfig struct {
-	Query    string
-	Country  string
-	Province string
-	City     string
-	Tags     []string
-	Timezone string
+	Query    string         `yaml:"query"`
+	Country  string         `yaml:"country"`
+	Province string         `yaml:"province"`
+	City     string         `yaml:"city"`
+	Tags     []string       `yaml:"tags"`
+	Timezone string         `yaml:"timezone"`
+	Schedule ScheduleConfig `yaml:"schedule"`
 }
 
 type EventbriteStrategyConfig struct {
-	Query          string
-	Region         string
-	PrettyLocation string
-	Tags           []string
+	Query          string         `yaml:"query"`
+	Region         string         `yaml:"region"`
+	PrettyLocation string         `yaml:"pretty_location"`
+	Tags           []string       `yaml:"tags"`
+	Schedule       ScheduleConfig `yaml:"schedule"`
 }
 
 type LumaStrategyConfig struct {
-	Region         string
-	Timezone       string
-	PrettyLocation string
-	Tags           []string
+	Region         string         `yaml:"region"`
+	Timezone       string         `yaml:"timezone"`
+	PrettyLocation string         `yaml:"pretty_location"`
+	Tags           []string       `yaml:"tags"`
+	Schedule       ScheduleConfig `yaml:"schedule"`
+}
+
+// ICSStrategyConfig reads one or more iCalendar feeds. Recurring events are
+// expanded up to Horizon ahead, and floating times are read in Timezone.
+type ICSStrategyConfig struct {
+	Name           string         `yaml:"name"`
+	URLs           []string       `yaml:"urls"`
+	Timezone       string         `yaml:"timezone"`
+	Horizon        time.Duration  `yaml:"horizon"`
+	PrettyLocation string         `yaml:"pretty_location"`
+	Tags           []string       `yaml:"tags"`
+	Schedule       ScheduleConfig `yaml:"schedule"`
+}
+
+// RSSStrategyConfig reads RSS or Atom feeds and keeps the entries that
+// mention a future date. Dates without a zone are read in Timezone.
+type RSSStrategyConfig struct {
+	Name           string         `yaml:"name"`
+	URLs           []string       `yaml:"urls"`
+	Timezone       string         `yaml:"timezone"`
+	PrettyLocation string         `yaml:"pretty_location"`
+	Tags           []string       `yaml:"tags"`
+	Schedule       ScheduleConfig `yaml:"schedule"`
+}
+
+// CustomStrategyConfig describes a site entirely in config: where its listing
+// lives, which links on it are events and how to read each event page.
+type CustomStrategyConfig struct {
+	Name string `yaml:"name"`
+	// ListingURL may contain {page}, which is replaced with 1..Pages.
+	ListingURL  string `yaml:"listing_url"`
+	LinkPattern string `yaml:"link_pattern"`
+	Pages       int    `yaml:"pages"`
+	// Scrolls is how many times a rendered page is scrolled to the bottom
+	// before it is read, for sites that load more events on scroll.
+	Scrolls int `yaml:"scrolls"`
+	// Render skips the plain HTTP fetch and always uses the browser.
+	Render         bool               `yaml:"render"`
+	Timezone       string             `yaml:"timezone"`
+	PrettyLocation string             `yaml:"pretty_location"`
+	Tags           []string           `yaml:"tags"`
+	Fields         CustomFieldsConfig `yaml:"fields"`
+	Schedule       ScheduleConfig     `yaml:"schedule"`
+}
+
+type CustomFieldsConfig struct {
+	Title       FieldSelector `yaml:"title"`
+	Date        FieldSelector `yaml:"date"`
+	Description FieldSelector `yaml:"description"`
+	Location    FieldSelector `yaml:"location"`
+	// Image defaults to the page's og:image. A Selector reads the element's
+	// src unless Attr names another attribute.
+	Image FieldSelector `yaml:"image"`
+}
+
+// FieldSelector reads one value from an event page, either the text (or Attr)
+// of the first element matching Selector, or the result of the JavaScript
+// expression JS. JS needs a browser, so it always renders the page.
+type FieldSelector struct {
+	Selector string `yaml:"selector"`
+	Attr     string `yaml:"attr"`
+	JS       string `yaml:"js"`
+}
+
+func (f FieldSelector) IsZero() bool {
+	return f.Selector == "" && f.JS == ""
+}
+
+// Key identifies a strategy by the search it performs, ignoring cosmetic
+// fields such as tags.
+func (c MeetupStrategyConfig) Key() string {
+	return normalizeKey("meetup", c.Query, c.Country, c.Province, c.City)
+}
+
+func (c EventbriteStrategyConfig) Key() string {
+	return normalizeKey("eventbrite", c.Query, c.Region)
+}
+
+func (c LumaStrategyConfig) Key() string {
+	return normalizeKey("luma", c.Region)
+}
+
+func (c ICSStrategyConfig) Key() string {
+	return normalizeKey(append([]string{"ics", c.Name}, c.URLs...)...)
+}
+
+func (c RSSStrategyConfig) Key() string {
+	return normalizeKey(append([]string{"rss", c.Name}, c.URLs...)...)
+}
+
+func (c CustomStrategyConfig) Key() string {
+	return normalizeKey("custom", c.Name, c.ListingURL)
+}
+
+func normalizeKey(parts ...string) string {
+	for i, part := range parts {
+		parts[i] = strings.ToLower(strings.TrimSpace(part))
+	}
+
+	return strings.Join(parts, "|")
+}
+
+// ImageCacheConfig enables thumbnails: event images are downloaded, scaled
+// down to Width pixels wide and kept in Path under their content hash.
+type ImageCacheConfig struct {
+	Path  string `yaml:"path"`
+	Width int    `yaml:"width"`
 }
 
 type ExtractorConfig struct {
-	Meetup     []MeetupStrategyConfig
-	Eventbrite []EventbriteStrategyConfig
-	Luma       []LumaStrategyConfig
+	Meetup     []MeetupStrategyConfig     `yaml:"meetup"`
+	Eventbrite []EventbriteStrategyConfig `yaml:"eventbrite"`
+	Luma       []LumaStrategyConfig       `yaml:"luma"`
+	ICS        []ICSStrategyConfig        `yaml:"ics"`
+	RSS        []RSSStrategyConfig        `yaml:"rss"`
+	Custom     []CustomStrategyConfig     `yaml:"custom"`
 }
 
 type Config struct {
-	UserAgent          string
-	EventStorePath     string
-	HTTPServerAddress  string
-	Extractors         ExtractorConfig
-	JobInterval        time.Duration
-	HTTPProxy          string
-	EnableProcessorJob bool
+	UserAgent          string          `yaml:"user_agent"`
+	EventStorePath     string          `yaml:"event_store_path"`
+	HTTPServerAddress  string          `yaml:"http_server_address"`
+	Extractors         ExtractorConfig `yaml:"extractors"`
+	JobInterval        time.Duration   `yaml:"job_interval"`
+	HTTPProxy          string          `yaml:"http_proxy"`
+	EnableProcessorJob bool            `yaml:"enable_processor_job"`
+	ReloadInterval     time.Duration   `yaml:"reload_interval"`
+	ProcessorSchedule  ScheduleConfig  `yaml:"processor_schedule"`
+	// A job that panics is restarted after JobRestartBackoff, doubling up to
+	// JobRestartBackoffMax, and quarantined after JobMaxFailures in a row.
+	JobRestartBackoff    time.Duration `yaml:"job_restart_backoff"`
+	JobRestartBackoffMax time.Duration `yaml:"job_restart_backoff_max"`
+	JobMaxFailures       int           `yaml:"job_max_failures"`
+	// Crawls share BrowserPoolSize long-lived browsers with up to
+	// BrowserMaxTabs tabs open on each.
+	BrowserPoolSize int `yaml:"browser_pool_size"`
+	BrowserMaxTabs  int `yaml:"browser_max_tabs"`
+	// RemoteBrowserURL points the pool at a Chrome DevTools endpoint, e.g. a
+	// sidecar container, instead of launching Chromium locally.
+	RemoteBrowserURL            string        `yaml:"remote_browser_url"`
+	RemoteBrowserHealthInterval time.Duration `yaml:"remote_browser_health_interval"`
+	// Requests for these DevTools resource types, or to URLs matching these
+	// wildcard patterns, are aborted during crawls.
+	BlockResourceTypes []string `yaml:"block_resource_types"`
+	BlockURLPatterns   []string `yaml:"block_url_patterns"`
+	// GeocoderDataPath optionally points at a GeoNames postal code or cities
+	// dump, loaded on top of the built-in gazetteer.
+	GeocoderDataPath string `yaml:"geocoder_data_path"`
+	// The processor re-reads the page of each upcoming event every
+	// StatusCheckInterval to spot cancellations. 0 disables it.
+	StatusCheckInterval time.Duration `yaml:"status_check_interval"`
+	// ImageCache is disabled unless its Path is set.
+	ImageCache ImageCacheConfig `yaml:"image_cache"`
 }
 
 func NewConfig() Config {
@@ -57,11 +213,40 @@ func NewConfig() Config {
 	}
 
 	return Config{
-		UserAgent:          defaultUserAgent,
-		EventStorePath:     filepath.Join(cwd, "events.db"),
-		HTTPServerAddress:  ":9898",
-		JobInterval:        4 * time.Hour,
-		EnableProcessorJob: true,
+		UserAgent:                   defaultUserAgent,
+		EventStorePath:              filepath.Join(cwd, "events.db"),
+		HTTPServerAddress:           ":9898",
+		JobInterval:                 4 * time.Hour,
+		ReloadInterval:              30 * time.Second,
+		JobRestartBackoff:           5 * time.Second,
+		JobRestartBackoffMax:        10 * time.Minute,
+		JobMaxFailures:              5,
+		BrowserPoolSize:             2,
+		BrowserMaxTabs:              4,
+		RemoteBrowserHealthInterval: 30 * time.Second,
+		BlockResourceTypes:          []string{"Image", "Media", "Font"},
+		BlockURLPatterns: []string{
+			"*google-analytics.com*",
+			"*googletagmanager.com*",
+			"*googlesyndication.com*",
+			"*doubleclick.net*",
+			"*facebook.net*",
+			"*connect.facebook.com*",
+			"*hotjar.com*",
+			"*segment.io*",
+			"*cdn.segment.com*",
+			"*amplitude.com*",
+			"*mixpanel.com*",
+			"*sentry.io*",
+			"*quantserve.com*",
+			"*scorecardresearch.com*",
+			"*bat.bing.com*",
+			"*ads-twitter.com*",
+			"*adsrvr.org*",
+		},
+		EnableProcessorJob:  true,
+		ImageCache:          ImageCacheConfig{Width: 480},
+		StatusCheckInterval: 24 * time.Hour,
 		Extractors: ExtractorConfig{
 			Meetup: []MeetupStrategyConfig{
 				{
@@ -163,8 +348,126 @@ func NewConfig() Config {
 	}
 }
 
+// UnmarshalYAML replaces the built-in extractor lists wholesale, so a config
+// file that lists its own searches doesn't inherit the default New York ones.
+func (c *ExtractorConfig) UnmarshalYAML(value *yaml.Node) error {
+	type plain ExtractorConfig
+	var extractors plain
+
+	if err := value.Decode(&extractors); err != nil {
+		return err
+	}
+
+	*c = ExtractorConfig(extractors)
+
+	return nil
+}
+
 var config Config = NewConfig()
+var configPath string
+var configLock sync.RWMutex
 
 func Get() Config {
+	configLock.RLock()
+	defer configLock.RUnlock()
+
 	return config
 }
+
+// Path returns the file the active config was loaded from, if any.
+func Path() string {
+	configLock.RLock()
+	defer configLock.RUnlock()
+
+	return configPath
+}
+
+// Load builds the active config from the built-in defaults, the file at path
+// (YAML or JSON, may be empty) and finally the CELEVE_* environment variables.
+// The result is validated before it replaces the active config.
+func Load(path string) error {
+	conf := NewConfig()
+
+	if path != "" {
+		if err := readFile(path, &conf); err != nil {
+			return err
+		}
+	}
+
+	if err := applyEnv(&conf); err != nil {
+		return err
+	}
+
+	if err := conf.Validate(); err != nil {
+		return err
+	}
+
+	configLock.Lock()
+	config = conf
+	configPath = path
+	configLock.Unlock()
+
+	return nil
+}
+
+func readFile(path string, conf *Config) error {
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return err
+	}
+
+	// YAML is a superset of JSON, so the same decoder handles both formats.
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(conf); err != nil && err != io.EOF {
+		return fmt.Errorf("unable to parse config %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func applyEnv(conf *Config) error {
+	if v, ok := os.LookupEnv(envUserAgent); ok {
+		conf.UserAgent = v
+	}
+
+	if v, ok := os.LookupEnv(envEventStorePath); ok {
+		conf.EventStorePath = v
+	}
+
+	if v, ok := os.LookupEnv(envHTTPServerAddress); ok {
+		conf.HTTPServerAddress = v
+	}
+
+	if v, ok := os.LookupEnv(envHTTPProxy); ok {
+		conf.HTTPProxy = v
+	}
+
+	if v, ok := os.LookupEnv(envRemoteBrowserURL); ok {
+		conf.RemoteBrowserURL = v
+	}
+
+	if v, ok := os.LookupEnv(envJobInterval); ok {
+		interval, err := time.ParseDuration(v)
+
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", envJobInterval, err)
+		}
+
+		conf.JobInterval = interval
+	}
+
+	if v, ok := os.LookupEnv(envEnableProcessorJob); ok {
+		enabled, err := strconv.ParseBool(v)
+
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", envEnableProcessorJob, err)
+		}
+
+		conf.EnableProcessorJob = enabled
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ScheduleConfig controls when a strategy or the processor runs. Set either
+// Interval or a standard 5-field Cron expression; if neither is set the job
+// falls back to the global JobInterval. Jitter adds a random delay of up to
+// that duration to every run, and ActiveHours ("08:00-22:00", may wrap past
+// midnight) restricts runs to a daily window in Timezone (default: local).
+type ScheduleConfig struct {
+	Interval    time.Duration `yaml:"interval"`
+	Cron        string        `yaml:"cron"`
+	Jitter      time.Duration `yaml:"jitter"`
+	ActiveHours string        `yaml:"active_hours"`
+	Timezone    string        `yaml:"timezone"`
+}
+
+// ParseActiveHours returns the start and end of an "HH:MM-HH:MM" window as
+// offsets from midnight.
+func ParseActiveHours(window string) (start, end time.Duration, err error) {
+	from, to, ok := strings.Cut(window, "-")
+
+	if !ok {
+		return 0, 0, fmt.Errorf("expected HH:MM-HH:MM, got %q", window)
+	}
+
+	if start, err = parseClock(from); err != nil {
+		return 0, 0, err
+	}
+
+	if end, err = parseClock(to); err != nil {
+		return 0, 0, err
+	}
+
+	if start == end {
+		return 0, 0, fmt.Errorf("window %q is empty", window)
+	}
+
+	return start, end, nil
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+
+	if err != nil {
+		return 0, fmt.Errorf("expected HH:MM, got %q", s)
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
@@ -0,0 +1,347 @@
+package config
+
+import (
+	"celeve/browser"
+	"celeve/util"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+var ebRegionPattern = regexp.MustCompile(`^(online|[a-z0-9]+(-[a-z0-9]+)*--[a-z0-9]+(-[a-z0-9]+)*)$`)
+var lumaRegionPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// ValidationError lists every problem found in a config, each prefixed with
+// the path of the offending entry, e.g. "extractors.meetup[2].timezone".
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid config:\n  %s", strings.Join(e.Problems, "\n  "))
+}
+
+type validator struct {
+	problems []string
+}
+
+func (v *validator) add(path string, format string, args ...any) {
+	v.problems = append(v.problems, path+": "+fmt.Sprintf(format, args...))
+}
+
+func (v *validator) required(path, value string) bool {
+	if strings.TrimSpace(value) == "" {
+		v.add(path, "must not be empty")
+		return false
+	}
+
+	return true
+}
+
+func (v *validator) timezone(path, value string) {
+	if !v.required(path, value) {
+		return
+	}
+
+	if _, err := time.LoadLocation(value); err != nil {
+		v.add(path, "unknown timezone %q", value)
+	}
+}
+
+func (v *validator) country(path, value string) (string, bool) {
+	if !v.required(path, value) {
+		return "", false
+	}
+
+	code, err := util.GetISO3166Alpha2(value)
+
+	if err != nil {
+		v.add(path, "unknown country %q", value)
+		return "", false
+	}
+
+	return code, true
+}
+
+func (v *validator) schedule(path string, c ScheduleConfig) {
+	if c.Interval != 0 && c.Cron != "" {
+		v.add(path, "set either interval or cron, not both")
+	}
+
+	if c.Interval < 0 {
+		v.add(path+".interval", "must not be negative, got %s", c.Interval)
+	}
+
+	if c.Jitter < 0 {
+		v.add(path+".jitter", "must not be negative, got %s", c.Jitter)
+	}
+
+	if c.Cron != "" {
+		if _, err := cron.ParseStandard(c.Cron); err != nil {
+			v.add(path+".cron", "invalid cron expression %q: %s", c.Cron, err)
+		}
+	}
+
+	if c.ActiveHours != "" {
+		if _, _, err := ParseActiveHours(c.ActiveHours); err != nil {
+			v.add(path+".active_hours", "%s", err)
+		}
+	}
+
+	if c.Timezone != "" {
+		v.timezone(path+".timezone", c.Timezone)
+	}
+}
+
+func (v *validator) feedURL(path, value string) {
+	if !v.required(path, value) {
+		return
+	}
+
+	u, err := url.Parse(value)
+
+	if err != nil || !slices.Contains([]string{"http", "https", "file"}, u.Scheme) || (u.Scheme != "file" && u.Host == "") {
+		v.add(path, "expected an http://, https:// or file:// URL, got %q", value)
+	}
+}
+
+func (v *validator) field(path string, f FieldSelector, required bool) {
+	if f.IsZero() {
+		if required {
+			v.add(path, "needs a selector or js expression")
+		}
+
+		return
+	}
+
+	if f.Selector != "" && f.JS != "" {
+		v.add(path, "set either selector or js, not both")
+	}
+
+	if f.Attr != "" && f.Selector == "" {
+		v.add(path+".attr", "only applies to a selector")
+	}
+}
+
+func (v *validator) duplicate(seen map[string]string, path, key string) {
+	if first, ok := seen[key]; ok {
+		v.add(path, "duplicates %s", first)
+		return
+	}
+
+	seen[key] = path
+}
+
+// Validate checks the whole config up front so that bad entries are reported
+// together at startup instead of failing one by one in the middle of a crawl.
+func (c Config) Validate() error {
+	v := &validator{}
+
+	v.required("user_agent", c.UserAgent)
+	v.required("event_store_path", c.EventStorePath)
+	v.required("http_server_address", c.HTTPServerAddress)
+
+	if c.JobInterval <= 0 {
+		v.add("job_interval", "must be positive, got %s", c.JobInterval)
+	}
+
+	if c.ReloadInterval < 0 {
+		v.add("reload_interval", "must not be negative, got %s", c.ReloadInterval)
+	}
+
+	if c.JobRestartBackoff <= 0 {
+		v.add("job_restart_backoff", "must be positive, got %s", c.JobRestartBackoff)
+	}
+
+	if c.JobRestartBackoffMax < c.JobRestartBackoff {
+		v.add("job_restart_backoff_max", "must be at least job_restart_backoff, got %s", c.JobRestartBackoffMax)
+	}
+
+	if c.JobMaxFailures <= 0 {
+		v.add("job_max_failures", "must be positive, got %d", c.JobMaxFailures)
+	}
+
+	if c.BrowserPoolSize <= 0 {
+		v.add("browser_pool_size", "must be positive, got %d", c.BrowserPoolSize)
+	}
+
+	if c.BrowserMaxTabs <= 0 {
+		v.add("browser_max_tabs", "must be positive, got %d", c.BrowserMaxTabs)
+	}
+
+	if c.RemoteBrowserURL != "" {
+		u, err := url.Parse(c.RemoteBrowserURL)
+
+		if err != nil || u.Host == "" || !slices.Contains([]string{"ws", "wss", "http", "https"}, u.Scheme) {
+			v.add("remote_browser_url", "expected a ws://, wss://, http:// or https:// DevTools URL, got %q", c.RemoteBrowserURL)
+		}
+
+		if c.RemoteBrowserHealthInterval < 0 {
+			v.add("remote_browser_health_interval", "must not be negative, got %s", c.RemoteBrowserHealthInterval)
+		}
+	}
+
+	for i, resourceType := range c.BlockResourceTypes {
+		if !browser.IsResourceType(resourceType) {
+			v.add(fmt.Sprintf("block_resource_types[%d]", i), "unknown resource type %q", resourceType)
+		}
+	}
+
+	for i, pattern := range c.BlockURLPatterns {
+		v.required(fmt.Sprintf("block_url_patterns[%d]", i), pattern)
+	}
+
+	if c.GeocoderDataPath != "" {
+		if _, err := os.Stat(c.GeocoderDataPath); err != nil {
+			v.add("geocoder_data_path", "%s", err)
+		}
+	}
+
+	if c.StatusCheckInterval < 0 {
+		v.add("status_check_interval", "must not be negative, got %s", c.StatusCheckInterval)
+	}
+
+	if c.ImageCache.Path != "" && c.ImageCache.Width <= 0 {
+		v.add("image_cache.width", "must be positive, got %d", c.ImageCache.Width)
+	}
+
+	v.schedule("processor_schedule", c.ProcessorSchedule)
+	c.Extractors.validate(v)
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+
+	return nil
+}
+
+func (c ExtractorConfig) validate(v *validator) {
+	seen := make(map[string]string)
+
+	for i, meetup := range c.Meetup {
+		path := fmt.Sprintf("extractors.meetup[%d]", i)
+
+		v.required(path+".query", meetup.Query)
+		v.required(path+".city", meetup.City)
+		v.timezone(path+".timezone", meetup.Timezone)
+
+		if code, ok := v.country(path+".country", meetup.Country); ok && code == "us" && len(meetup.Province) != 2 {
+			v.add(path+".province", "US province should be 2 characters, not %q", meetup.Province)
+		}
+
+		v.schedule(path+".schedule", meetup.Schedule)
+		v.duplicate(seen, path, meetup.Key())
+	}
+
+	for i, eb := range c.Eventbrite {
+		path := fmt.Sprintf("extractors.eventbrite[%d]", i)
+
+		v.required(path+".query", eb.Query)
+
+		if v.required(path+".region", eb.Region) && !ebRegionPattern.MatchString(eb.Region) {
+			v.add(path+".region", "malformed region %q, expected a slug like \"ny--new-york\"", eb.Region)
+		}
+
+		v.schedule(path+".schedule", eb.Schedule)
+		v.duplicate(seen, path, eb.Key())
+	}
+
+	for i, luma := range c.Luma {
+		path := fmt.Sprintf("extractors.luma[%d]", i)
+
+		if v.required(path+".region", luma.Region) && !lumaRegionPattern.MatchString(luma.Region) {
+			v.add(path+".region", "malformed region %q, expected a slug like \"nyc\"", luma.Region)
+		}
+
+		v.timezone(path+".timezone", luma.Timezone)
+		v.schedule(path+".schedule", luma.Schedule)
+		v.duplicate(seen, path, luma.Key())
+	}
+
+	for i, ics := range c.ICS {
+		path := fmt.Sprintf("extractors.ics[%d]", i)
+
+		v.required(path+".name", ics.Name)
+
+		if len(ics.URLs) == 0 {
+			v.add(path+".urls", "must list at least one feed")
+		}
+
+		for j, feed := range ics.URLs {
+			v.feedURL(fmt.Sprintf("%s.urls[%d]", path, j), feed)
+		}
+
+		v.timezone(path+".timezone", ics.Timezone)
+
+		if ics.Horizon < 0 {
+			v.add(path+".horizon", "must not be negative, got %s", ics.Horizon)
+		}
+
+		v.schedule(path+".schedule", ics.Schedule)
+		v.duplicate(seen, path, ics.Key())
+	}
+
+	for i, rss := range c.RSS {
+		path := fmt.Sprintf("extractors.rss[%d]", i)
+
+		v.required(path+".name", rss.Name)
+
+		if len(rss.URLs) == 0 {
+			v.add(path+".urls", "must list at least one feed")
+		}
+
+		for j, feed := range rss.URLs {
+			v.feedURL(fmt.Sprintf("%s.urls[%d]", path, j), feed)
+		}
+
+		v.timezone(path+".timezone", rss.Timezone)
+		v.schedule(path+".schedule", rss.Schedule)
+		v.duplicate(seen, path, rss.Key())
+	}
+
+	for i, custom := range c.Custom {
+		path := fmt.Sprintf("extractors.custom[%d]", i)
+
+		v.required(path+".name", custom.Name)
+
+		if v.required(path+".listing_url", custom.ListingURL) {
+			u, err := url.Parse(strings.ReplaceAll(custom.ListingURL, "{page}", "1"))
+
+			if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+				v.add(path+".listing_url", "expected an http:// or https:// URL, got %q", custom.ListingURL)
+			}
+		}
+
+		if v.required(path+".link_pattern", custom.LinkPattern) {
+			if _, err := regexp.Compile(custom.LinkPattern); err != nil {
+				v.add(path+".link_pattern", "invalid regular expression: %s", err)
+			}
+		}
+
+		if custom.Pages < 0 {
+			v.add(path+".pages", "must not be negative, got %d", custom.Pages)
+		}
+
+		if custom.Scrolls < 0 {
+			v.add(path+".scrolls", "must not be negative, got %d", custom.Scrolls)
+		}
+
+		if custom.Timezone != "" {
+			v.timezone(path+".timezone", custom.Timezone)
+		}
+
+		v.field(path+".fields.title", custom.Fields.Title, true)
+		v.field(path+".fields.date", custom.Fields.Date, true)
+		v.field(path+".fields.description", custom.Fields.Description, false)
+		v.field(path+".fields.location", custom.Fields.Location, false)
+		v.field(path+".fields.image", custom.Fields.Image, false)
+		v.schedule(path+".schedule", custom.Schedule)
+		v.duplicate(seen, path, custom.Key())
+	}
+}
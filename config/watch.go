@@ -0,0 +1,61 @@
+package config
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Watch polls the loaded config file and calls onChange with the new config
+// every time it is modified and still valid. Invalid edits are logged and the
+// previous config stays active. It returns once ctx is done, or immediately if
+// no config file was loaded or reloading is disabled.
+func Watch(ctx context.Context, onChange func(Config)) {
+	path := Path()
+	interval := Get().ReloadInterval
+
+	if path == "" || interval == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	lastMod := modTime(path)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		mod := modTime(path)
+
+		if mod.Equal(lastMod) {
+			continue
+		}
+
+		lastMod = mod
+		log.Info().Msgf("Config %s changed, reloading", path)
+
+		if err := Load(path); err != nil {
+			log.Error().Err(err).Msg("Unable to reload config, keeping the previous one")
+			continue
+		}
+
+		onChange(Get())
+	}
+}
+
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+
+	if err != nil {
+		return time.Time{}
+	}
+
+	return info.ModTime()
+}
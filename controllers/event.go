@@ -2,9 +2,11 @@ package controllers
 
 import (
 	"celeve/gateways"
+	"celeve/models"
 	"encoding/json"
 	"io"
 	"net/http"
+	"slices"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -14,11 +16,24 @@ var defaultLimit = 50
 var defaultOffset = 0
 
 type getEventsParams struct {
-	Limit  *int     `json:"limit"`
-	Offset *int     `json:"offset"`
-	Start  *int64   `json:"start"`
-	End    *int64   `json:"end"`
-	Tags   []string `json:"tags"`
+	Limit             *int          `json:"limit"`
+	Offset            *int          `json:"offset"`
+	Start             *int64        `json:"start"`
+	End               *int64        `json:"end"`
+	Tags              []string      `json:"tags"`
+	Status            []string      `json:"status"`
+	FreeOnly          bool          `json:"free_only"`
+	MaxPrice          *float64      `json:"max_price"`
+	Near              *nearbyParams `json:"near"`
+	Sort              string        `json:"sort"`
+	IncludeDuplicates bool          `json:"include_duplicates"`
+}
+
+// nearbyParams limits results to venues within RadiusKm of a point.
+type nearbyParams struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	RadiusKm  float64 `json:"radius_km"`
 }
 
 type getEventParams struct {
@@ -57,13 +72,61 @@ func GetEvents(eg gateways.SqliteGateway, w http.ResponseWriter, r *http.Request
 		params.End = &end
 	}
 
-	events, err := eg.GetEvents(
-		time.Unix(*params.Start, 0),
-		time.Unix(*params.End, 0),
-		*params.Limit,
-		*params.Offset,
-		params.Tags,
-	)
+	filter := gateways.EventFilter{
+		Start:             time.Unix(*params.Start, 0),
+		End:               time.Unix(*params.End, 0),
+		Limit:             *params.Limit,
+		Offset:            *params.Offset,
+		Tags:              params.Tags,
+		FreeOnly:          params.FreeOnly,
+		MaxPrice:          params.MaxPrice,
+		IncludeDuplicates: params.IncludeDuplicates,
+	}
+
+	for _, status := range params.Status {
+		if !slices.Contains(models.EventStatuses, models.EventStatus(status)) {
+			http.Error(w, "Unknown status "+status, http.StatusBadRequest)
+			return
+		}
+
+		filter.Statuses = append(filter.Statuses, models.EventStatus(status))
+	}
+
+	if params.MaxPrice != nil && *params.MaxPrice < 0 {
+		http.Error(w, "max_price can't be negative", http.StatusBadRequest)
+		return
+	}
+
+	if params.Near != nil {
+		near := params.Near
+
+		if near.Latitude < -90 || near.Latitude > 90 || near.Longitude < -180 || near.Longitude > 180 || near.RadiusKm <= 0 {
+			http.Error(w, "near needs a valid latitude, longitude and a positive radius_km", http.StatusBadRequest)
+			return
+		}
+
+		filter.Near = &gateways.GeoFilter{
+			Latitude:  near.Latitude,
+			Longitude: near.Longitude,
+			RadiusKm:  near.RadiusKm,
+		}
+	}
+
+	switch params.Sort {
+	case "":
+	case "distance":
+		if filter.Near == nil {
+			http.Error(w, "sort by distance needs near", http.StatusBadRequest)
+			return
+		}
+
+		filter.SortByDistance = true
+	default:
+		http.Error(w, "Unknown sort "+params.Sort, http.StatusBadRequest)
+		return
+	}
+
+	events, err := eg.GetEvents(filter)
 
 	if err != nil {
 		log.Error().Err(err).Msg("Unable to get events")
@@ -132,3 +195,43 @@ func GetEvent(eg gateways.SqliteGateway, w http.ResponseWriter, r *http.Request)
 		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
 	}
 }
+
+// GetEventHistory returns every recorded change to an event, oldest first.
+func GetEventHistory(eg gateways.SqliteGateway, w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to read request body")
+		http.Error(w, "Unable to read request body", http.StatusBadRequest)
+		return
+	}
+
+	defer r.Body.Close()
+
+	var params getEventParams
+
+	if err := json.Unmarshal(body, &params); err != nil {
+		log.Error().Err(err).Msg("Unable to parse request body")
+		http.Error(w, "Unable to parse request body", http.StatusBadRequest)
+		return
+	}
+
+	if params.ID == nil {
+		http.Error(w, "No event ID provided", http.StatusBadRequest)
+		return
+	}
+
+	revisions, err := eg.GetEventRevisions(*params.ID)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Error while getting event history")
+		http.Error(w, "Error while getting event history", http.StatusInternalServerError)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(revisions); err != nil {
+		log.Error().Err(err).Msg("Failed to encode JSON")
+		w.Header().Del("Content-Type")
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,17 @@
+package controllers
+
+import (
+	"celeve/jobs"
+	"encoding/json"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+func GetJobs(manager *jobs.Manager, w http.ResponseWriter, r *http.Request) {
+	if err := json.NewEncoder(w).Encode(manager.Statuses()); err != nil {
+		log.Error().Err(err).Msg("Failed to encode JSON")
+		w.Header().Del("Content-Type")
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
@@ -1,7 +1,10 @@
 package extractors
 
-import "celeve/models"
+import (
+	"celeve/models"
+	"context"
+)
 
 type Extractor interface {
-	GetEvent() (*models.CalendarEvent, error)
+	GetEvent(ctx context.Context) (*models.CalendarEvent, error)
 }
@@ -0,0 +1,272 @@
+package extractors
+
+import (
+	"celeve/browser"
+	"celeve/config"
+	"celeve/fetcher"
+	"celeve/models"
+	"celeve/util"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	md "github.com/JohannesKaufmann/html-to-markdown"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/chromedp/chromedp"
+	"github.com/rs/zerolog/log"
+)
+
+// customExtractor reads an event page using the selectors or JavaScript
+// expressions of a config.CustomStrategyConfig.
+type customExtractor struct {
+	url     string
+	config  config.CustomStrategyConfig
+	pool    *browser.Pool
+	fetcher *fetcher.Fetcher
+	tz      *time.Location
+}
+
+type customPage struct {
+	title       string
+	dateStr     string
+	description string
+	location    string
+	image       string
+	statusText  string
+}
+
+func NewCustomExtractor(url string, conf config.CustomStrategyConfig, pool *browser.Pool, fetcher *fetcher.Fetcher) Extractor {
+	var loc *time.Location
+
+	if conf.Timezone != "" {
+		var err error
+
+		if loc, err = time.LoadLocation(conf.Timezone); err != nil {
+			log.Panic().Err(err).Msg("Invalid custom strategy timezone")
+		}
+	}
+
+	return &customExtractor{
+		url:     url,
+		config:  conf,
+		pool:    pool,
+		fetcher: fetcher,
+		tz:      loc,
+	}
+}
+
+func (s *customExtractor) GetEvent(ctx context.Context) (*models.CalendarEvent, error) {
+	var page customPage
+	var err error
+
+	metadata := make(map[string]string)
+	metadata["fetch-path"] = fetchPathHTTP
+	metadata["custom-source"] = s.config.Name
+
+	if s.needsBrowser() {
+		err = errors.New("fields need a browser")
+	} else {
+		page, err = s.fetchPage(ctx)
+	}
+
+	if err != nil || page.title == "" || page.dateStr == "" {
+		metadata["fetch-path"] = fetchPathChrome
+
+		if page, err = s.renderPage(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if page.title == "" {
+		return nil, fmt.Errorf("unable to extract title for url: %s", s.url)
+	}
+
+	if page.dateStr == "" {
+		return nil, fmt.Errorf("unable to extract date for url: %s", s.url)
+	}
+
+	metadata["raw-time"] = page.dateStr
+	start, end, err := parseDateRange(page.dateStr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if s.tz != nil {
+		start = util.InjectTimezone(start, s.tz)
+		end = util.InjectTimezone(end, s.tz)
+	}
+
+	venue := VenueFromText("", page.location)
+	location := page.location
+
+	if location == "" {
+		location = s.config.PrettyLocation
+	}
+
+	converter := md.NewConverter("", true, nil)
+	markdown, _ := converter.ConvertString(page.description)
+
+	event := models.CalendarEvent{
+		Name:        page.title,
+		Location:    location,
+		Status:      orScheduled(eventStatus(page.title, page.statusText)),
+		Venue:       venue,
+		ImageURL:    resolveURL(s.url, page.image),
+		Tags:        s.config.Tags,
+		OriginURL:   s.url,
+		Description: markdown,
+		StartTime:   start,
+		EndTime:     end,
+		Metadata:    metadata,
+	}
+	util.SetIdentityFromURL(&event)
+
+	return &event, nil
+}
+
+// needsBrowser reports whether the page must be rendered, either because the
+// config asks for it or because a field is a JavaScript expression.
+func (s *customExtractor) needsBrowser() bool {
+	fields := s.config.Fields
+
+	if s.config.Render {
+		return true
+	}
+
+	for _, f := range []config.FieldSelector{fields.Title, fields.Date, fields.Description, fields.Location, fields.Image} {
+		if f.JS != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// imageField reads the src of the image selector unless another attribute
+// is configured.
+func (s *customExtractor) imageField() config.FieldSelector {
+	f := s.config.Fields.Image
+
+	if f.Selector != "" && f.Attr == "" {
+		f.Attr = "src"
+	}
+
+	return f
+}
+
+func (s *customExtractor) fetchPage(ctx context.Context) (customPage, error) {
+	doc, err := s.fetcher.GetDocument(ctx, s.url)
+
+	if err != nil {
+		return customPage{}, err
+	}
+
+	fields := s.config.Fields
+	page := customPage{
+		title:       selectText(doc, fields.Title),
+		dateStr:     selectText(doc, fields.Date),
+		description: selectHTML(doc, fields.Description),
+		location:    selectText(doc, fields.Location),
+		image:       selectText(doc, s.imageField()),
+		statusText:  allStatusText(doc),
+	}
+
+	if fields.Image.IsZero() {
+		page.image = pageImage(doc, s.url)
+	}
+
+	return page, nil
+}
+
+func (s *customExtractor) renderPage(ctx context.Context) (customPage, error) {
+	var page customPage
+
+	ctx, cancel, err := s.pool.Tab(ctx)
+
+	if err != nil {
+		return page, err
+	}
+
+	defer cancel()
+
+	fields := s.config.Fields
+	err = chromedp.Run(ctx,
+		chromedp.Navigate(s.url),
+		browser.Scroll(s.config.Scrolls),
+		chromedp.Evaluate(fieldScript(fields.Title, false), &page.title),
+		chromedp.Evaluate(fieldScript(fields.Date, false), &page.dateStr),
+		chromedp.Evaluate(fieldScript(fields.Description, true), &page.description),
+		chromedp.Evaluate(fieldScript(fields.Location, false), &page.location),
+		chromedp.Evaluate(imageScript(s.imageField()), &page.image),
+		chromedp.Evaluate(pageStatusScript, &page.statusText),
+	)
+
+	page.title = strings.TrimSpace(page.title)
+	page.dateStr = strings.TrimSpace(page.dateStr)
+	page.location = strings.TrimSpace(page.location)
+
+	return page, err
+}
+
+func selectText(doc *goquery.Document, f config.FieldSelector) string {
+	if f.Selector == "" {
+		return ""
+	}
+
+	sel := doc.Find(f.Selector).First()
+
+	if f.Attr != "" {
+		return strings.TrimSpace(sel.AttrOr(f.Attr, ""))
+	}
+
+	return lineText(sel)
+}
+
+func selectHTML(doc *goquery.Document, f config.FieldSelector) string {
+	if f.Selector == "" || f.Attr != "" {
+		return selectText(doc, f)
+	}
+
+	html, _ := doc.Find(f.Selector).First().Html()
+
+	return strings.TrimSpace(html)
+}
+
+// imageScript reads the image field, or the page's preview image when the
+// config doesn't say where the image is.
+func imageScript(f config.FieldSelector) string {
+	if f.IsZero() {
+		return pageImageScript
+	}
+
+	return fieldScript(f, false)
+}
+
+// fieldScript builds a JavaScript expression that evaluates to the field as a
+// string, or "" when it can't be found.
+func fieldScript(f config.FieldSelector, html bool) string {
+	if f.JS != "" {
+		return fmt.Sprintf(`(() => { const v = (%s); return v == null ? '' : String(v); })()`, f.JS)
+	}
+
+	if f.Selector == "" {
+		return `''`
+	}
+
+	selector, _ := json.Marshal(f.Selector)
+
+	if f.Attr != "" {
+		attr, _ := json.Marshal(f.Attr)
+		return fmt.Sprintf(`document.querySelector(%s)?.getAttribute(%s) ?? ''`, selector, attr)
+	}
+
+	if html {
+		return fmt.Sprintf(`document.querySelector(%s)?.innerHTML ?? ''`, selector)
+	}
+
+	return fmt.Sprintf(`document.querySelector(%s)?.innerText ?? ''`, selector)
+}
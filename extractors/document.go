@@ -0,0 +1,101 @@
+package extractors
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+const fetchPathHTTP = "http"
+const fetchPathChrome = "chrome"
+
+// firstText returns the trimmed text of the first element matching selector.
+func firstText(doc *goquery.Document, selector string) string {
+	return strings.TrimSpace(doc.Find(selector).First().Text())
+}
+
+// allText returns the trimmed text of every element matching selector.
+func allText(doc *goquery.Document, selector string) []string {
+	var result []string
+
+	doc.Find(selector).Each(func(_ int, sel *goquery.Selection) {
+		result = append(result, strings.TrimSpace(sel.Text()))
+	})
+
+	return result
+}
+
+// blockElements start and end a line of lineText, as they do in innerText.
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "br": true,
+	"dd": true, "div": true, "dl": true, "dt": true, "figcaption": true,
+	"figure": true, "footer": true, "form": true, "h1": true, "h2": true,
+	"h3": true, "h4": true, "h5": true, "h6": true, "header": true, "hr": true,
+	"li": true, "main": true, "nav": true, "ol": true, "p": true, "pre": true,
+	"section": true, "table": true, "td": true, "th": true, "tr": true, "ul": true,
+}
+
+// hiddenElements hold text that innerText leaves out.
+var hiddenElements = map[string]bool{
+	"head": true, "noscript": true, "script": true, "style": true, "template": true,
+}
+
+// lineText approximates innerText: the text of sel in document order, with
+// each block element on its own line and runs of whitespace collapsed.
+func lineText(sel *goquery.Selection) string {
+	var lines []string
+	var line strings.Builder
+
+	endLine := func() {
+		if text := strings.Join(strings.Fields(line.String()), " "); text != "" {
+			lines = append(lines, text)
+		}
+
+		line.Reset()
+	}
+
+	var walk func(node *html.Node)
+
+	walk = func(node *html.Node) {
+		switch node.Type {
+		case html.TextNode:
+			line.WriteString(node.Data)
+			return
+		case html.ElementNode:
+			if hiddenElements[node.Data] {
+				return
+			}
+
+			if blockElements[node.Data] {
+				endLine()
+				defer endLine()
+			}
+		}
+
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+
+	for _, node := range sel.Nodes {
+		walk(node)
+		endLine()
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// outerHTML returns the outer HTML of every element in sel, joined by
+// newlines.
+func outerHTML(sel *goquery.Selection) string {
+	var result []string
+
+	sel.Each(func(_ int, el *goquery.Selection) {
+		if html, err := goquery.OuterHtml(el); err == nil {
+			result = append(result, html)
+		}
+	})
+
+	return strings.Join(result, "\n")
+}
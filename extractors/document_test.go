@@ -0,0 +1,51 @@
+package extractors
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestLineText(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "inline elements in document order",
+			html: `<div>Hello <b>World</b> again <span>end</span></div>`,
+			want: "Hello World again end",
+		},
+		{
+			name: "mixed inline date",
+			html: `<time>Starts <b>Mar 3</b> at 7pm</time>`,
+			want: "Starts Mar 3 at 7pm",
+		},
+		{
+			name: "block elements on their own lines",
+			html: `<div><p>123 Main St</p><p>Brooklyn,  NY</p>Free<br>RSVP</div>`,
+			want: "123 Main St\nBrooklyn, NY\nFree\nRSVP",
+		},
+		{
+			name: "scripts and styles left out",
+			html: `<div><style>.a{}</style>$10<script>var price = 20;</script></div>`,
+			want: "$10",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.html))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := lineText(doc.Find("body").Children().First()); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,80 +1,108 @@
 package extractors
 
 import (
+	"celeve/browser"
+	"celeve/fetcher"
 	"celeve/models"
 	"celeve/util"
 	"context"
 	"errors"
+	"strings"
 	"time"
 
 	md "github.com/JohannesKaufmann/html-to-markdown"
+	"github.com/PuerkitoBio/goquery"
 	"github.com/chromedp/chromedp"
 	"github.com/markusmobius/go-dateparser"
+	"github.com/rs/zerolog/log"
 )
 
 type eventbriteExtractor struct {
 	url      string
 	location string
 	tags     []string
-	opts     []chromedp.ExecAllocatorOption
+	pool     *browser.Pool
+	fetcher  *fetcher.Fetcher
 }
 
-func NewEventbriteExtractor(url, location string, tags []string, opts []chromedp.ExecAllocatorOption) Extractor {
+type eventbritePage struct {
+	title       string
+	description string
+	dateStr     string
+	venueName   string
+	address     string
+	price       string
+	currency    string
+	image       string
+	statusText  string
+}
+
+func NewEventbriteExtractor(url, location string, tags []string, pool *browser.Pool, fetcher *fetcher.Fetcher) Extractor {
 	return &eventbriteExtractor{
 		url:      url,
 		location: location,
 		tags:     tags,
-		opts:     opts,
+		pool:     pool,
+		fetcher:  fetcher,
 	}
 }
 
-func (s *eventbriteExtractor) GetEvent() (*models.CalendarEvent, error) {
-	var title string
-	var description string
-	var dateStr string
+func (s *eventbriteExtractor) GetEvent(ctx context.Context) (*models.CalendarEvent, error) {
+	var page eventbritePage
+
 	metadata := make(map[string]string)
+	metadata["fetch-path"] = fetchPathHTTP
+	doc, err := s.fetcher.GetDocument(ctx, s.url)
 
-	ctx, cancel := chromedp.NewExecAllocator(context.Background(), s.opts...)
+	if err == nil {
+		ld := newJSONLDExtractor(s.url, s.location, s.tags, nil, s.fetcher)
 
-	defer cancel()
+		event, ldErr := ld.fromDocument(doc)
 
-	ctx, cancel = chromedp.NewContext(ctx)
+		if ldErr == nil {
+			return event, nil
+		}
 
-	defer cancel()
+		log.Debug().Err(ldErr).Msgf("Falling back to DOM scraping for %s", s.url)
 
-	chromedp.Run(ctx,
-		chromedp.Navigate(s.url),
-		chromedp.WaitReady("body"),
-		chromedp.Evaluate(`window.scrollTo(0, document.body.scrollHeight);`, nil),
-		chromedp.Sleep(1*time.Second),
-		chromedp.Evaluate(`document.querySelector('h1')?.textContent || ''`, &title),
-		chromedp.Evaluate(
-			`document.querySelector('.summary')?.childNodes[0]?.outerHTML.trim() || ''`,
-			&description,
-		),
-		chromedp.Evaluate(
-			`Array.from(document.querySelectorAll('.date-info__full-datetime')).map(e => e.textContent.trim()).find(t => t === '' || t)`,
-			&dateStr,
-		),
-	)
+		page = s.pageFromDocument(doc)
+	}
+
+	if err != nil || page.title == "" || page.dateStr == "" {
+		metadata["fetch-path"] = fetchPathChrome
 
-	if title == "" {
+		if page, err = s.renderPage(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if page.title == "" {
 		return nil, errors.New("unable to find title")
 	}
 
-	metadata["raw-time"] = dateStr
-	start, end, err := parseDateRange(dateStr)
+	metadata["raw-time"] = page.dateStr
+	start, end, err := parseDateRange(page.dateStr)
 
 	if err != nil {
 		return nil, err
 	}
 
 	converter := md.NewConverter("", true, nil)
-	markdown, _ := converter.ConvertString(description)
+	markdown, _ := converter.ConvertString(page.description)
+	venue := VenueFromText(page.venueName, page.address)
+	location := s.location
+
+	if !venue.IsZero() {
+		location = venue.String()
+	}
 
 	event := models.CalendarEvent{
-		Name:        title,
-		Location:    s.location,
+		Name:        page.title,
+		Location:    location,
+		Status:      orScheduled(eventStatus(page.title, page.statusText)),
+		Venue:       venue,
+		Ticketing:   pageTicketing(page.price, page.currency, s.url),
+		ImageURL:    resolveURL(s.url, page.image),
 		Tags:        s.tags,
 		OriginURL:   s.url,
 		Description: markdown,
@@ -82,17 +110,73 @@ func (s *eventbriteExtractor) GetEvent() (*models.CalendarEvent, error) {
 		EndTime:     end,
 		Metadata:    metadata,
 	}
-	event.ID = util.GetEventHash(event)
+	util.SetIdentityFromURL(&event)
 
 	return &event, nil
 }
 
+func (s *eventbriteExtractor) pageFromDocument(doc *goquery.Document) eventbritePage {
+	return eventbritePage{
+		title:       firstText(doc, "h1"),
+		description: strings.TrimSpace(outerHTML(doc.Find(".summary").First().Contents().First())),
+		dateStr:     firstText(doc, ".date-info__full-datetime"),
+		venueName:   firstText(doc, ".location-info__address-text"),
+		address:     lineText(doc.Find(".location-info__address").First()),
+		price:       lineText(doc.Find(".conversion-bar").First()),
+		currency:    pageCurrency(doc),
+		image:       pageImage(doc, s.url),
+		statusText:  allStatusText(doc),
+	}
+}
+
+func (s *eventbriteExtractor) renderPage(ctx context.Context) (eventbritePage, error) {
+	var page eventbritePage
+
+	ctx, cancel, err := s.pool.Tab(ctx)
+
+	if err != nil {
+		return page, err
+	}
+
+	defer cancel()
+
+	chromedp.Run(ctx,
+		chromedp.Navigate(s.url),
+		chromedp.WaitReady("body"),
+		chromedp.Evaluate(`window.scrollTo(0, document.body.scrollHeight);`, nil),
+		chromedp.Sleep(1*time.Second),
+		chromedp.Evaluate(`document.querySelector('h1')?.textContent || ''`, &page.title),
+		chromedp.Evaluate(
+			`document.querySelector('.summary')?.childNodes[0]?.outerHTML.trim() || ''`,
+			&page.description,
+		),
+		chromedp.Evaluate(
+			`Array.from(document.querySelectorAll('.date-info__full-datetime')).map(e => e.textContent.trim()).find(t => t === '' || t)`,
+			&page.dateStr,
+		),
+		chromedp.Evaluate(`document.querySelector('.location-info__address-text')?.innerText.trim() || ''`, &page.venueName),
+		chromedp.Evaluate(`document.querySelector('.location-info__address')?.innerText || ''`, &page.address),
+		chromedp.Evaluate(`document.querySelector('.conversion-bar')?.innerText || ''`, &page.price),
+		chromedp.Evaluate(pageCurrencyScript, &page.currency),
+		chromedp.Evaluate(pageImageScript, &page.image),
+		chromedp.Evaluate(pageStatusScript, &page.statusText),
+	)
+
+	return page, nil
+}
+
 func parseDateRange(dateStr string) (s time.Time, e time.Time, err error) {
+	return searchDateRange(nil, dateStr)
+}
+
+// searchDateRange finds the dates in text and treats the last two as the start
+// and end, or the only one as a start with a two hour duration.
+func searchDateRange(cfg *dateparser.Configuration, dateStr string) (s time.Time, e time.Time, err error) {
 	defer func() {
 		util.LogRecover()
 	}()
 
-	_, dates, err := dateparser.Search(nil, dateStr)
+	_, dates, err := dateparser.Search(cfg, dateStr)
 
 	if err != nil {
 		return
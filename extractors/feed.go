@@ -0,0 +1,109 @@
+package extractors
+
+import (
+	"celeve/models"
+	"celeve/util"
+	"celeve/util/feed"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	md "github.com/JohannesKaufmann/html-to-markdown"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/markusmobius/go-dateparser"
+	"github.com/rs/zerolog/log"
+)
+
+// feedEventHorizon is how far ahead a date found in a feed entry may be and
+// still be taken as the event's time rather than noise.
+const feedEventHorizon = 365 * 24 * time.Hour
+
+var errNoFutureDate = errors.New("no plausible future date")
+
+// feedEntryExtractor turns a blog or news feed entry into an event by looking
+// for a date in its title and summary.
+type feedEntryExtractor struct {
+	entry    feed.Entry
+	feed     string
+	location string
+	tags     []string
+	tz       *time.Location
+}
+
+func NewFeedEntryExtractor(entry feed.Entry, feedURL, location string, tags []string, tz string) Extractor {
+	loc, err := time.LoadLocation(tz)
+
+	if err != nil {
+		log.Panic().Err(err).Msg("Invalid feed timezone")
+	}
+
+	return &feedEntryExtractor{
+		entry:    entry,
+		feed:     feedURL,
+		location: location,
+		tags:     tags,
+		tz:       loc,
+	}
+}
+
+func (s *feedEntryExtractor) GetEvent(ctx context.Context) (*models.CalendarEvent, error) {
+	now := time.Now()
+	summary := plainText(s.entry.Summary)
+
+	// Incomplete dates such as "Friday, Nov 20" are resolved towards the
+	// future, since posts announce upcoming events.
+	cfg := &dateparser.Configuration{
+		DefaultTimezone:     s.tz,
+		PreferredDateSource: dateparser.Future,
+	}
+
+	start, end, err := searchDateRange(cfg, s.entry.Title+"\n"+summary)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !start.After(now) || start.After(now.Add(feedEventHorizon)) {
+		return nil, fmt.Errorf("%w in %s", errNoFutureDate, s.entry.Link)
+	}
+
+	if !end.After(start) || end.Sub(start) > 24*time.Hour {
+		end = start.Add(2 * time.Hour)
+	}
+
+	converter := md.NewConverter("", true, nil)
+	markdown, _ := converter.ConvertString(s.entry.Summary)
+
+	event := models.CalendarEvent{
+		Name:        s.entry.Title,
+		Location:    s.location,
+		Status:      orScheduled(StatusFromTitle(s.entry.Title)),
+		Tags:        s.tags,
+		ImageURL:    resolveURL(s.entry.Link, s.entry.Image),
+		OriginURL:   s.entry.Link,
+		Description: markdown,
+		StartTime:   start,
+		EndTime:     end,
+		Metadata: map[string]string{
+			"feed-url":       s.feed,
+			"feed-guid":      s.entry.GUID,
+			"feed-published": s.entry.Published.Format(time.RFC3339),
+		},
+	}
+	util.SetIdentity(&event, util.FeedSource("rss", s.feed), util.FeedEntrySourceID(s.entry.GUID, s.entry.Link))
+
+	return &event, nil
+}
+
+// plainText strips the markup from an HTML fragment.
+func plainText(fragment string) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(fragment))
+
+	if err != nil {
+		return fragment
+	}
+
+	return lineText(doc.Selection)
+}
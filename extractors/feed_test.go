@@ -0,0 +1,105 @@
+package extractors
+
+import (
+	"celeve/util/feed"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errAny = errors.New("any error")
+
+func TestFeedEntryDateWindow(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	day := func(days int) time.Time {
+		d := time.Now().In(loc).AddDate(0, 0, days)
+		return time.Date(d.Year(), d.Month(), d.Day(), 19, 0, 0, 0, loc)
+	}
+	format := func(t time.Time) string {
+		return t.Format("2 January 2006 15:04")
+	}
+
+	soon := day(10)
+
+	tests := []struct {
+		name  string
+		entry feed.Entry
+		start time.Time
+		end   time.Time
+		// wantErr is the error expected, or errAny for whatever error.
+		wantErr error
+	}{
+		{
+			name:  "date in title",
+			entry: feed.Entry{Title: "Hack night " + format(soon)},
+			start: soon,
+			end:   soon.Add(2 * time.Hour),
+		},
+		{
+			name:  "date in xhtml summary",
+			entry: feed.Entry{Title: "Hack night", Summary: `<div xmlns="http://www.w3.org/1999/xhtml"><p>Join us <b>` + format(soon) + `</b></p></div>`},
+			start: soon,
+			end:   soon.Add(2 * time.Hour),
+		},
+		{
+			name:  "range",
+			entry: feed.Entry{Title: "Workshop", Summary: "From " + format(soon) + " to " + format(soon.Add(3*time.Hour))},
+			start: soon,
+			end:   soon.Add(3 * time.Hour),
+		},
+		{
+			name:  "range over a day is ignored",
+			entry: feed.Entry{Title: "Workshop", Summary: "From " + format(soon) + " to " + format(soon.AddDate(0, 0, 3))},
+			start: soon,
+			end:   soon.Add(2 * time.Hour),
+		},
+		{
+			name:    "past date",
+			entry:   feed.Entry{Title: "Recap of " + format(day(-10))},
+			wantErr: errNoFutureDate,
+		},
+		{
+			name:    "beyond the horizon",
+			entry:   feed.Entry{Title: "Save the date " + format(day(400))},
+			wantErr: errNoFutureDate,
+		},
+		{
+			name:    "no date",
+			entry:   feed.Entry{Title: "Thanks for coming", Summary: "The slides are up on GitHub"},
+			wantErr: errAny,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.entry.Link = "https://example.com/posts/1"
+			event, err := NewFeedEntryExtractor(tt.entry, "https://example.com/feed.xml", "New York", nil, loc.String()).GetEvent(context.Background())
+
+			if tt.wantErr != nil {
+				if err == nil {
+					t.Fatalf("got event at %v, want an error", event.StartTime)
+				}
+
+				if tt.wantErr != errAny && !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got %v, want %v", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !event.StartTime.Equal(tt.start) || !event.EndTime.Equal(tt.end) {
+				t.Errorf("got %v–%v, want %v–%v", event.StartTime, event.EndTime, tt.start, tt.end)
+			}
+		})
+	}
+}
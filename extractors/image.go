@@ -0,0 +1,43 @@
+package extractors
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const pageImageSelector = `meta[property="og:image"], meta[property="og:image:url"], meta[name="twitter:image"]`
+
+// pageImageScript evaluates to the same image as pageImage in a rendered page.
+var pageImageScript = fmt.Sprintf(`document.querySelector(%q)?.content || ''`, pageImageSelector)
+
+// pageImage returns the cover image a page advertises for link previews.
+func pageImage(doc *goquery.Document, pageURL string) string {
+	return resolveURL(pageURL, doc.Find(pageImageSelector).First().AttrOr("content", ""))
+}
+
+// resolveURL makes ref absolute relative to base, returning "" for anything
+// that isn't an http(s) URL.
+func resolveURL(base, ref string) string {
+	ref = strings.TrimSpace(ref)
+
+	if ref == "" {
+		return ""
+	}
+
+	baseURL, err := url.Parse(base)
+
+	if err != nil {
+		return ""
+	}
+
+	resolved, err := baseURL.Parse(ref)
+
+	if err != nil || (resolved.Scheme != "http" && resolved.Scheme != "https") {
+		return ""
+	}
+
+	return resolved.String()
+}
@@ -0,0 +1,391 @@
+package extractors
+
+import (
+	"celeve/fetcher"
+	"celeve/models"
+	"celeve/util"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	md "github.com/JohannesKaufmann/html-to-markdown"
+	"github.com/PuerkitoBio/goquery"
+)
+
+var jsonLDTimeFormats = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05.000Z07:00",
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// jsonLDExtractor reads the schema.org Event that many event pages embed as
+// <script type="application/ld+json">, which is far more stable than CSS
+// selectors.
+type jsonLDExtractor struct {
+	url      string
+	location string
+	tags     []string
+	fetcher  *fetcher.Fetcher
+	tz       *time.Location
+}
+
+// NewJSONLDExtractor returns an extractor for pages that embed a schema.org
+// Event. Dates without an offset are read in tz, or UTC if tz is nil.
+func NewJSONLDExtractor(url, location string, tags []string, tz *time.Location, fetcher *fetcher.Fetcher) Extractor {
+	return newJSONLDExtractor(url, location, tags, tz, fetcher)
+}
+
+func newJSONLDExtractor(url, location string, tags []string, tz *time.Location, fetcher *fetcher.Fetcher) *jsonLDExtractor {
+	if tz == nil {
+		tz = time.UTC
+	}
+
+	return &jsonLDExtractor{
+		url:      url,
+		location: location,
+		tags:     tags,
+		fetcher:  fetcher,
+		tz:       tz,
+	}
+}
+
+func (s *jsonLDExtractor) GetEvent(ctx context.Context) (*models.CalendarEvent, error) {
+	doc, err := s.fetcher.GetDocument(ctx, s.url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.fromDocument(doc)
+}
+
+func (s *jsonLDExtractor) fromDocument(doc *goquery.Document) (*models.CalendarEvent, error) {
+	ld := findJSONLDEvent(doc)
+
+	if ld == nil {
+		return nil, errors.New("no schema.org Event found")
+	}
+
+	name := ldString(ld["name"])
+
+	if name == "" {
+		return nil, errors.New("schema.org Event has no name")
+	}
+
+	start, err := parseJSONLDTime(ldString(ld["startDate"]), s.tz)
+
+	if err != nil {
+		return nil, fmt.Errorf("schema.org Event has no usable startDate: %w", err)
+	}
+
+	end, err := parseJSONLDTime(ldString(ld["endDate"]), s.tz)
+
+	if err != nil || end.Before(start) {
+		end = start.Add(2 * time.Hour)
+	}
+
+	metadata := map[string]string{
+		"fetch-path": fetchPathHTTP,
+		"extractor":  "json-ld",
+		"raw-time":   ldString(ld["startDate"]) + " " + ldString(ld["endDate"]),
+	}
+	venue := jsonLDVenue(ld["location"])
+	attendanceMode := schemaEnum(ldString(ld["eventAttendanceMode"]))
+
+	if attendanceMode == "OnlineEventAttendanceMode" {
+		venue.Online = true
+	}
+
+	location := s.location
+
+	if !venue.IsZero() {
+		location = venue.String()
+	}
+
+	setIfPresent(metadata, "attendance-mode", attendanceMode)
+	setIfPresent(metadata, "organizer", strings.Join(ldNames(ld["organizer"]), ", "))
+
+	converter := md.NewConverter("", true, nil)
+	description, _ := converter.ConvertString(ldString(ld["description"]))
+	image := resolveURL(s.url, ldURL(ld["image"]))
+	status := jsonLDStatus(ld)
+
+	if status == "" {
+		status = eventStatus(name, allStatusText(doc))
+	}
+
+	if image == "" {
+		image = pageImage(doc, s.url)
+	}
+
+	event := models.CalendarEvent{
+		Name:        strings.TrimSpace(name),
+		Location:    location,
+		Status:      orScheduled(status),
+		Venue:       venue,
+		Ticketing:   jsonLDTicketing(ld),
+		ImageURL:    image,
+		Tags:        s.tags,
+		OriginURL:   s.url,
+		Description: description,
+		StartTime:   start,
+		EndTime:     end,
+		Metadata:    metadata,
+	}
+	util.SetIdentityFromURL(&event)
+
+	return &event, nil
+}
+
+// findJSONLDEvent returns the first schema.org Event (or subtype such as
+// MusicEvent) in the document's JSON-LD blocks, looking inside arrays and
+// @graph containers.
+func findJSONLDEvent(doc *goquery.Document) map[string]any {
+	var found map[string]any
+
+	doc.Find(`script[type="application/ld+json"]`).EachWithBreak(func(_ int, sel *goquery.Selection) bool {
+		var data any
+
+		if err := json.Unmarshal([]byte(sel.Text()), &data); err != nil {
+			return true
+		}
+
+		found = findEventNode(data)
+
+		return found == nil
+	})
+
+	return found
+}
+
+func findEventNode(data any) map[string]any {
+	switch v := data.(type) {
+	case []any:
+		for _, item := range v {
+			if node := findEventNode(item); node != nil {
+				return node
+			}
+		}
+	case map[string]any:
+		for _, t := range ldStrings(v["@type"]) {
+			if strings.HasSuffix(t, "Event") {
+				return v
+			}
+		}
+
+		if graph, ok := v["@graph"]; ok {
+			return findEventNode(graph)
+		}
+	}
+
+	return nil
+}
+
+func parseJSONLDTime(value string, tz *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	for _, layout := range jsonLDTimeFormats {
+		if t, err := time.ParseInLocation(layout, value, tz); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognized date %q", value)
+}
+
+// jsonLDVenue reads a Place, VirtualLocation, plain string or list of those.
+// Only the first physical place is used.
+func jsonLDVenue(value any) models.Venue {
+	var venue models.Venue
+
+	if s, ok := value.(string); ok {
+		venue.Name = s
+		return venue
+	}
+
+	for _, place := range ldObjects(value) {
+		if strings.HasSuffix(ldString(place["@type"]), "VirtualLocation") {
+			venue.Online = true
+			continue
+		}
+
+		venue.Name = ldString(place["name"])
+
+		if address, ok := place["address"].(string); ok {
+			venue.StreetAddress = address
+		}
+
+		for _, address := range ldObjects(place["address"]) {
+			venue.StreetAddress = ldString(address["streetAddress"])
+			venue.City = ldString(address["addressLocality"])
+			venue.Region = ldString(address["addressRegion"])
+			venue.PostalCode = ldString(address["postalCode"])
+			venue.Country = ldString(address["addressCountry"])
+		}
+
+		for _, geo := range ldObjects(place["geo"]) {
+			latitude, latErr := strconv.ParseFloat(ldString(geo["latitude"]), 64)
+			longitude, lonErr := strconv.ParseFloat(ldString(geo["longitude"]), 64)
+
+			if latErr == nil && lonErr == nil {
+				venue.Latitude = &latitude
+				venue.Longitude = &longitude
+			}
+		}
+
+		break
+	}
+
+	return venue
+}
+
+// jsonLDTicketing reads the event's Offers and AggregateOffers. The event is
+// only sold out when every offer is.
+func jsonLDTicketing(ld map[string]any) models.Ticketing {
+	var ticketing models.Ticketing
+
+	offers := ldObjects(ld["offers"])
+	soldOut := 0
+
+	for _, offer := range offers {
+		for _, key := range []string{"price", "lowPrice", "highPrice"} {
+			if price, err := strconv.ParseFloat(ldString(offer[key]), 64); err == nil {
+				setPrice(&ticketing, price)
+			}
+		}
+
+		if ticketing.Currency == "" {
+			ticketing.Currency = currencyCode(ldString(offer["priceCurrency"]))
+		}
+
+		if ticketing.URL == "" {
+			ticketing.URL = ldURL(offer["url"])
+		}
+
+		if availability := schemaEnum(ldString(offer["availability"])); availability == "SoldOut" {
+			soldOut++
+		}
+	}
+
+	ticketing.SoldOut = len(offers) > 0 && soldOut == len(offers)
+
+	switch free := ld["isAccessibleForFree"].(type) {
+	case bool:
+		ticketing.Free = ticketing.Free || free
+	case string:
+		ticketing.Free = ticketing.Free || strings.EqualFold(free, "true")
+	}
+
+	return ticketing
+}
+
+// ldString reads a scalar JSON-LD value, or the name/@id of an object.
+func ldString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []any:
+		if len(v) > 0 {
+			return ldString(v[0])
+		}
+	case map[string]any:
+		if name := ldString(v["name"]); name != "" {
+			return name
+		}
+
+		return ldString(v["@id"])
+	}
+
+	return ""
+}
+
+func ldStrings(value any) []string {
+	if list, ok := value.([]any); ok {
+		var result []string
+
+		for _, item := range list {
+			result = append(result, ldString(item))
+		}
+
+		return result
+	}
+
+	return []string{ldString(value)}
+}
+
+func ldObjects(value any) []map[string]any {
+	switch v := value.(type) {
+	case map[string]any:
+		return []map[string]any{v}
+	case []any:
+		var result []map[string]any
+
+		for _, item := range v {
+			if obj, ok := item.(map[string]any); ok {
+				result = append(result, obj)
+			}
+		}
+
+		return result
+	}
+
+	return nil
+}
+
+func ldNames(value any) []string {
+	var names []string
+
+	for _, obj := range ldObjects(value) {
+		if name := ldString(obj["name"]); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	if s, ok := value.(string); ok && s != "" {
+		names = append(names, s)
+	}
+
+	return names
+}
+
+// ldURL reads an image-like value: a URL, an ImageObject or a list of either.
+func ldURL(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []any:
+		if len(v) > 0 {
+			return ldURL(v[0])
+		}
+	case map[string]any:
+		if url := ldString(v["url"]); url != "" {
+			return url
+		}
+
+		return ldString(v["contentUrl"])
+	}
+
+	return ""
+}
+
+// schemaEnum strips the schema.org prefix from enumeration values, e.g.
+// "https://schema.org/EventCancelled" becomes "EventCancelled".
+func schemaEnum(value string) string {
+	return value[strings.LastIndex(value, "/")+1:]
+}
+
+func setIfPresent(metadata map[string]string, key, value string) {
+	if value != "" {
+		metadata[key] = value
+	}
+}
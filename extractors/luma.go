@@ -1,10 +1,14 @@
 package extractors
 
 import (
+	"celeve/browser"
+	"celeve/fetcher"
 	"celeve/models"
 	"celeve/util"
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	md "github.com/JohannesKaufmann/html-to-markdown"
@@ -13,15 +17,33 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// lumaPriceSelector matches the registration card, which shows "Free", the
+// ticket price or "Sold Out".
+const lumaPriceSelector = `.event-register-card, .register-section`
+
 type lumaExtractor struct {
 	url      string
 	location string
 	tags     []string
-	opts     []chromedp.ExecAllocatorOption
+	pool     *browser.Pool
+	fetcher  *fetcher.Fetcher
 	tz       *time.Location
 }
 
-func NewLumaExtractor(url, location string, tags []string, tz string, opts []chromedp.ExecAllocatorOption) Extractor {
+type lumaPage struct {
+	title       string
+	description string
+	dateStr     string
+	venueName   string
+	address     string
+	price       string
+	currency    string
+	image       string
+	statusText  string
+	ticketing   models.Ticketing
+}
+
+func NewLumaExtractor(url, location string, tags []string, tz string, pool *browser.Pool, fetcher *fetcher.Fetcher) Extractor {
 	loc, err := time.LoadLocation(tz)
 
 	if err != nil {
@@ -32,63 +54,62 @@ func NewLumaExtractor(url, location string, tags []string, tz string, opts []chr
 		url:      url,
 		location: location,
 		tags:     tags,
-		opts:     opts,
+		pool:     pool,
+		fetcher:  fetcher,
 		tz:       loc,
 	}
 }
 
-func (s *lumaExtractor) GetEvent() (*models.CalendarEvent, error) {
-	var title string
-	var description string
-	var dateStr string
+func (s *lumaExtractor) GetEvent(ctx context.Context) (*models.CalendarEvent, error) {
 	metadata := make(map[string]string)
+	metadata["fetch-path"] = fetchPathHTTP
+	page, err := s.fetchPage(ctx)
 
-	ctx, cancel := chromedp.NewExecAllocator(context.Background(), s.opts...)
-
-	defer cancel()
+	if err != nil || page.title == "" || page.dateStr == "" {
+		metadata["fetch-path"] = fetchPathChrome
 
-	ctx, cancel = chromedp.NewContext(ctx)
-
-	defer cancel()
-
-	chromedp.Run(ctx,
-		chromedp.Navigate(s.url),
-		chromedp.WaitReady("body"),
-		chromedp.Sleep(1*time.Second),
-		chromedp.Evaluate(
-			`Array.from(document.getElementsByClassName('title-wrapper')).map(el => el ? el.textContent.trim() : '').shift() || '';`,
-			&title,
-		),
-		chromedp.Evaluate(
-			`Array.from(document.getElementsByClassName('content')).map(e => e.outerHTML).join('\n');`,
-			&description,
-		),
-		chromedp.Evaluate(
-			`document.querySelector('.meta .title').innerText + " " + document.querySelector('.meta .desc').innerText`,
-			&dateStr,
-		),
-	)
+		if page, err = s.renderPage(ctx); err != nil {
+			return nil, err
+		}
+	}
 
-	if title == "" {
+	if page.title == "" {
 		return nil, errors.New("unable to find title")
 	}
 
-	if dateStr == "" {
+	if page.dateStr == "" {
 		return nil, errors.New("unable to find date")
 	}
 
-	metadata["raw-time"] = dateStr
-	start, end, err := parseLumaDate(dateStr)
+	metadata["raw-time"] = page.dateStr
+	start, end, err := parseLumaDate(page.dateStr)
 
 	if err != nil {
 		return nil, err
 	}
 
 	converter := md.NewConverter("", true, nil)
-	markdown, _ := converter.ConvertString(description)
+	markdown, _ := converter.ConvertString(page.description)
+	venue := VenueFromText(page.venueName, page.address)
+	location := s.location
+
+	if !venue.IsZero() {
+		location = venue.String()
+	}
+
+	ticketing := page.ticketing
+
+	if ticketing.IsZero() {
+		ticketing = pageTicketing(page.price, page.currency, s.url)
+	}
+
 	event := models.CalendarEvent{
-		Name:        title,
-		Location:    s.location,
+		Name:        page.title,
+		Location:    location,
+		Status:      orScheduled(eventStatus(page.title, page.statusText)),
+		Venue:       venue,
+		Ticketing:   ticketing,
+		ImageURL:    resolveURL(s.url, page.image),
 		Tags:        s.tags,
 		OriginURL:   s.url,
 		Description: markdown,
@@ -96,11 +117,82 @@ func (s *lumaExtractor) GetEvent() (*models.CalendarEvent, error) {
 		EndTime:     util.InjectTimezone(end, s.tz),
 		Metadata:    metadata,
 	}
-	event.ID = util.GetEventHash(event)
+	util.SetIdentityFromURL(&event)
 
 	return &event, nil
 }
 
+func (s *lumaExtractor) fetchPage(ctx context.Context) (lumaPage, error) {
+	doc, err := s.fetcher.GetDocument(ctx, s.url)
+
+	if err != nil {
+		return lumaPage{}, err
+	}
+
+	page := lumaPage{
+		title:       firstText(doc, ".title-wrapper"),
+		description: outerHTML(doc.Find(".content")),
+	}
+
+	if date, desc := firstText(doc, ".meta .title"), firstText(doc, ".meta .desc"); date != "" {
+		page.dateStr = date + " " + desc
+	}
+
+	// The second meta row, after the date, is the location.
+	place := doc.Find(".meta").Eq(1)
+	page.venueName = strings.TrimSpace(place.Find(".title").First().Text())
+	page.address = strings.TrimSpace(place.Find(".desc").First().Text())
+	page.price = lineText(doc.Find(lumaPriceSelector).First())
+	page.currency = pageCurrency(doc)
+	page.image = pageImage(doc, s.url)
+	page.statusText = allStatusText(doc)
+
+	// Paid events carry their ticket types as schema.org offers.
+	if ld := findJSONLDEvent(doc); ld != nil {
+		page.ticketing = jsonLDTicketing(ld)
+	}
+
+	return page, nil
+}
+
+func (s *lumaExtractor) renderPage(ctx context.Context) (lumaPage, error) {
+	var page lumaPage
+
+	ctx, cancel, err := s.pool.Tab(ctx)
+
+	if err != nil {
+		return page, err
+	}
+
+	defer cancel()
+
+	chromedp.Run(ctx,
+		chromedp.Navigate(s.url),
+		chromedp.WaitReady("body"),
+		chromedp.Sleep(1*time.Second),
+		chromedp.Evaluate(
+			`Array.from(document.getElementsByClassName('title-wrapper')).map(el => el ? el.textContent.trim() : '').shift() || '';`,
+			&page.title,
+		),
+		chromedp.Evaluate(
+			`Array.from(document.getElementsByClassName('content')).map(e => e.outerHTML).join('\n');`,
+			&page.description,
+		),
+		chromedp.Evaluate(
+			`document.querySelector('.meta .title').innerText + " " + document.querySelector('.meta .desc').innerText`,
+			&page.dateStr,
+		),
+		chromedp.Evaluate(`document.querySelectorAll('.meta')[1]?.querySelector('.title')?.innerText || ''`, &page.venueName),
+		chromedp.Evaluate(`document.querySelectorAll('.meta')[1]?.querySelector('.desc')?.innerText || ''`, &page.address),
+		chromedp.Evaluate(fmt.Sprintf(`document.querySelector(%q)?.innerText || ''`, lumaPriceSelector), &page.price),
+		chromedp.Evaluate(pageCurrencyScript, &page.currency),
+		chromedp.Evaluate(pageImageScript, &page.image),
+		chromedp.Evaluate(pageStatusScript, &page.statusText),
+	)
+
+	return page, nil
+}
+
 func parseLumaDate(dateStr string) (time.Time, time.Time, error) {
 	_, dates, err := dateparser.Search(nil, dateStr)
 
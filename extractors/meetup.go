@@ -1,31 +1,66 @@
 package extractors
 
 import (
-	"celeve/config"
+	"celeve/browser"
+	"celeve/fetcher"
 	"celeve/models"
 	"celeve/util"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
+	md "github.com/JohannesKaufmann/html-to-markdown"
+	"github.com/PuerkitoBio/goquery"
 	"github.com/chromedp/chromedp"
 	"github.com/markusmobius/go-dateparser"
 	"github.com/rs/zerolog/log"
 )
 
+const meetupDescriptionSelector = `#event-details .break-words`
+const meetupVenueSelector = `[data-testid="venue-name-link"], [data-testid="venue-name-value"]`
+const meetupAddressSelector = `[data-testid="location-info"]`
+const meetupHostSelector = `[data-testid="group-name"], [data-event-label="event-home-group-name"]`
+const meetupAttendeesSelector = `#attendees h2`
+const meetupPriceSelector = `[data-event-label="action-bar"]`
+
+var meetupCountPattern = regexp.MustCompile(`\d[\d,]*`)
 var timeFmt = "Monday, January 2, 2006\n3:04 PM"
 var timeFmt2 = "Monday, January 2, 2006 at 13:04 PM"
 
 type meetupExtractor struct {
-	url       string
-	userAgent string
-	location  string
-	tags      []string
-	tz        *time.Location
+	url      string
+	pool     *browser.Pool
+	fetcher  *fetcher.Fetcher
+	location string
+	tags     []string
+	tz       *time.Location
+}
+
+type meetupPage struct {
+	h1s     []string
+	times   []string
+	details meetupDetails
+}
+
+// meetupDetails holds the parts of an event page beyond its title and time.
+// The JSON tags match the object built by meetupDetailsScript.
+type meetupDetails struct {
+	Description string `json:"description"`
+	Venue       string `json:"venue"`
+	Address     string `json:"address"`
+	Host        string `json:"host"`
+	Attendees   string `json:"attendees"`
+	Price       string `json:"price"`
+	Currency    string `json:"currency"`
+	Image       string `json:"image"`
+	StatusText  string `json:"statusText"`
 }
 
-func NewMeetupExtractor(url string, location string, tags []string, tz string) Extractor {
+func NewMeetupExtractor(url string, location string, tags []string, tz string, pool *browser.Pool, fetcher *fetcher.Fetcher) Extractor {
 	loc, err := time.LoadLocation(tz)
 
 	if err != nil {
@@ -33,43 +68,46 @@ func NewMeetupExtractor(url string, location string, tags []string, tz string) E
 	}
 
 	return &meetupExtractor{
-		url:       url,
-		userAgent: config.Get().UserAgent,
-		location:  location,
-		tags:      append(tags, "meetup"),
-		tz:        loc,
+		url:      url,
+		pool:     pool,
+		fetcher:  fetcher,
+		location: location,
+		tags:     append(tags, "meetup"),
+		tz:       loc,
 	}
 }
 
-func (s *meetupExtractor) GetEvent() (*models.CalendarEvent, error) {
-	var htmlContent string
+func (s *meetupExtractor) GetEvent(ctx context.Context) (*models.CalendarEvent, error) {
+	var page meetupPage
+
 	metadata := make(map[string]string)
+	metadata["fetch-path"] = fetchPathHTTP
+	doc, err := s.fetcher.GetDocument(ctx, s.url)
 
-	opts := append(
-		chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.UserAgent(s.userAgent),
-	)
+	if err == nil {
+		ld := newJSONLDExtractor(s.url, s.location, s.tags, s.tz, s.fetcher)
 
-	ctx, cancel := chromedp.NewExecAllocator(context.Background(), opts...)
+		event, ldErr := ld.fromDocument(doc)
+		page = s.pageFromDocument(doc)
 
-	defer cancel()
+		if ldErr == nil {
+			addMeetupDetails(event, page.details)
+			return event, nil
+		}
 
-	ctx, cancel = chromedp.NewContext(ctx)
+		log.Debug().Err(ldErr).Msgf("Falling back to DOM scraping for %s", s.url)
+	}
 
-	defer cancel()
+	if err != nil || len(page.h1s) == 0 || len(page.times) == 0 {
+		metadata["fetch-path"] = fetchPathChrome
 
-	var h1s []string
-	var times []string
+		if page, err = s.renderPage(ctx); err != nil {
+			return nil, err
+		}
+	}
 
-	chromedp.Run(ctx,
-		chromedp.Navigate(s.url),
-		chromedp.WaitReady("body"),
-		chromedp.OuterHTML("html", &htmlContent),
-		chromedp.Evaluate(`window.scrollTo(0, document.body.scrollHeight);`, nil),
-		chromedp.Sleep(1*time.Second),
-		chromedp.Evaluate(`Array.from(document.querySelectorAll('h1')).map(el => el.innerText)`, &h1s),
-		chromedp.Evaluate(`Array.from(document.querySelectorAll('time')).map(el => el.innerText)`, &times),
-	)
+	h1s := page.h1s
+	times := page.times
 
 	if len(h1s) == 0 {
 		return nil, fmt.Errorf("unable to extract title for url: %s", s.url)
@@ -95,11 +133,58 @@ func (s *meetupExtractor) GetEvent() (*models.CalendarEvent, error) {
 		Tags:      s.tags,
 		Metadata:  metadata,
 	}
-	event.ID = util.GetEventHash(event)
+	addMeetupDetails(&event, page.details)
 
 	return &event, nil
 }
 
+func (s *meetupExtractor) pageFromDocument(doc *goquery.Document) meetupPage {
+	page := meetupPage{h1s: allText(doc, "h1")}
+
+	doc.Find("time").Each(func(_ int, sel *goquery.Selection) {
+		page.times = append(page.times, lineText(sel))
+	})
+
+	description, _ := doc.Find(meetupDescriptionSelector).First().Html()
+	page.details = meetupDetails{
+		Description: strings.TrimSpace(description),
+		Venue:       lineText(doc.Find(meetupVenueSelector).First()),
+		Address:     lineText(doc.Find(meetupAddressSelector).First()),
+		Host:        lineText(doc.Find(meetupHostSelector).First()),
+		Attendees:   lineText(doc.Find(meetupAttendeesSelector).First()),
+		Price:       lineText(doc.Find(meetupPriceSelector).First()),
+		Currency:    pageCurrency(doc),
+		Image:       doc.Find(pageImageSelector).First().AttrOr("content", ""),
+		StatusText:  allStatusText(doc),
+	}
+
+	return page
+}
+
+func (s *meetupExtractor) renderPage(ctx context.Context) (meetupPage, error) {
+	var page meetupPage
+
+	ctx, cancel, err := s.pool.Tab(ctx)
+
+	if err != nil {
+		return page, err
+	}
+
+	defer cancel()
+
+	chromedp.Run(ctx,
+		chromedp.Navigate(s.url),
+		chromedp.WaitReady("body"),
+		chromedp.Evaluate(`window.scrollTo(0, document.body.scrollHeight);`, nil),
+		chromedp.Sleep(1*time.Second),
+		chromedp.Evaluate(`Array.from(document.querySelectorAll('h1')).map(el => el.innerText)`, &page.h1s),
+		chromedp.Evaluate(`Array.from(document.querySelectorAll('time')).map(el => el.innerText)`, &page.times),
+		chromedp.Evaluate(meetupDetailsScript(), &page.details),
+	)
+
+	return page, nil
+}
+
 func parseMeetupDate(dateStr string) (time.Time, time.Time, error) {
 	_, dates, err := dateparser.Search(nil, dateStr)
 
@@ -115,3 +200,76 @@ func parseMeetupDate(dateStr string) (time.Time, time.Time, error) {
 
 	return time.Time{}, time.Time{}, errors.New("could not find a date")
 }
+
+func meetupDetailsScript() string {
+	quote := func(selector string) string {
+		encoded, _ := json.Marshal(selector)
+		return string(encoded)
+	}
+
+	return fmt.Sprintf(`(() => {
+		const text = s => document.querySelector(s)?.innerText?.trim() || '';
+		return {
+			description: document.querySelector(%s)?.innerHTML?.trim() || '',
+			venue: text(%s),
+			address: text(%s),
+			host: text(%s),
+			attendees: text(%s),
+			price: text(%s),
+			currency: %s,
+			image: %s,
+			statusText: %s,
+		};
+	})()`,
+		quote(meetupDescriptionSelector),
+		quote(meetupVenueSelector),
+		quote(meetupAddressSelector),
+		quote(meetupHostSelector),
+		quote(meetupAttendeesSelector),
+		quote(meetupPriceSelector),
+		pageCurrencyScript,
+		pageImageScript,
+		pageStatusScript,
+	)
+}
+
+// addMeetupDetails fills in whatever event is missing from the page details,
+// then rehashes it. Values already found in JSON-LD take precedence.
+func addMeetupDetails(event *models.CalendarEvent, details meetupDetails) {
+	if event.Description == "" && details.Description != "" {
+		converter := md.NewConverter("", true, nil)
+		event.Description, _ = converter.ConvertString(details.Description)
+	}
+
+	online := event.Venue.Online || strings.Contains(strings.ToLower(details.Venue), "online")
+
+	if event.Venue.IsZero() && details.Venue != "" && !online {
+		event.Venue = VenueFromText(details.Venue, details.Address)
+	}
+
+	event.Venue.Online = online
+
+	if !event.Venue.IsZero() {
+		event.Location = event.Venue.String()
+	}
+
+	if event.Status == "" || event.Status == models.StatusScheduled {
+		event.Status = orScheduled(eventStatus(event.Name, details.StatusText))
+	}
+
+	if event.ImageURL == "" {
+		event.ImageURL = resolveURL(event.OriginURL, details.Image)
+	}
+
+	if event.Ticketing.IsZero() {
+		event.Ticketing = pageTicketing(details.Price, details.Currency, event.OriginURL)
+	}
+
+	setIfPresent(event.Metadata, "host", details.Host)
+
+	if count := meetupCountPattern.FindString(details.Attendees); count != "" {
+		event.Metadata["attendees"] = strings.ReplaceAll(count, ",", "")
+	}
+
+	util.SetIdentityFromURL(event)
+}
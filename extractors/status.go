@@ -0,0 +1,130 @@
+package extractors
+
+import (
+	"celeve/fetcher"
+	"celeve/models"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// statusBannerSelector matches the alerts and banners sites put on an event
+// page once the event has changed.
+const statusBannerSelector = `[role="alert"], [data-testid*="cancel"], [data-testid*="postpone"], [class*="cancelled"], [class*="canceled"], [class*="postponed"]`
+
+// pageStatusScript evaluates to the text allStatusText reads, in a rendered
+// page.
+var pageStatusScript = fmt.Sprintf(`Array.from(document.querySelectorAll(%q)).map(e => e.innerText).join('\n')`, statusBannerSelector)
+
+var schemaStatuses = map[string]models.EventStatus{
+	"EventScheduled":   models.StatusScheduled,
+	"EventCancelled":   models.StatusCancelled,
+	"EventPostponed":   models.StatusPostponed,
+	"EventRescheduled": models.StatusRescheduled,
+	"EventMovedOnline": models.StatusMovedOnline,
+}
+
+var statusWords = map[string]models.EventStatus{
+	"canceled":    models.StatusCancelled,
+	"cancelled":   models.StatusCancelled,
+	"postponed":   models.StatusPostponed,
+	"rescheduled": models.StatusRescheduled,
+}
+
+// titleStatusPattern matches a status that leads a title, such as
+// "CANCELLED: Board meeting" or "[Postponed] Picnic".
+var titleStatusPattern = regexp.MustCompile(`(?i)^\W*(cancel+ed|postponed|rescheduled)\b`)
+
+// bannerStatusPattern matches a banner line that starts with a status, or
+// says the event has one.
+var bannerStatusPattern = regexp.MustCompile(`(?im)^\W*(cancel+ed|postponed|rescheduled)\b|\b(?:event|it)\s+(?:has\s+been|had\s+been|is|was)\s+(cancel+ed|postponed|rescheduled)\b`)
+
+// jsonLDStatus reads a schema.org eventStatus, or "" if there is none.
+func jsonLDStatus(ld map[string]any) models.EventStatus {
+	return schemaStatuses[schemaEnum(ldString(ld["eventStatus"]))]
+}
+
+// StatusFromTitle finds a status announced at the start of a title, such as
+// "CANCELLED: Board meeting", or "" if there is none. A status word elsewhere
+// in the title, as in "The Cancelled Podcast Live", doesn't count.
+func StatusFromTitle(title string) models.EventStatus {
+	if m := titleStatusPattern.FindStringSubmatch(title); m != nil {
+		return statusWords[strings.ToLower(m[1])]
+	}
+
+	return ""
+}
+
+// bannerStatus finds a status announced in the text of an event page's
+// alerts and banners, or "" if there is none.
+func bannerStatus(text string) models.EventStatus {
+	if m := bannerStatusPattern.FindStringSubmatch(text); m != nil {
+		return statusWords[strings.ToLower(m[1]+m[2])]
+	}
+
+	return ""
+}
+
+// eventStatus finds a status announced in an event's title or its page's
+// banners, or "" if neither announces one.
+func eventStatus(title, banners string) models.EventStatus {
+	if status := StatusFromTitle(title); status != "" {
+		return status
+	}
+
+	return bannerStatus(banners)
+}
+
+// allStatusText returns the text of the page's alerts and banners.
+func allStatusText(doc *goquery.Document) string {
+	var texts []string
+
+	doc.Find(statusBannerSelector).Each(func(_ int, sel *goquery.Selection) {
+		texts = append(texts, lineText(sel))
+	})
+
+	return strings.Join(texts, "\n")
+}
+
+func pageStatus(doc *goquery.Document) models.EventStatus {
+	return eventStatus(firstText(doc, "h1"), allStatusText(doc))
+}
+
+// orScheduled treats an event nobody said anything about as going ahead.
+func orScheduled(status models.EventStatus) models.EventStatus {
+	if status == "" {
+		return models.StatusScheduled
+	}
+
+	return status
+}
+
+// CheckStatus re-reads an event page over plain HTTP and returns the status
+// it announces, or "" if it doesn't say. Pages that are gone count as
+// cancelled.
+func CheckStatus(ctx context.Context, f *fetcher.Fetcher, url string) (models.EventStatus, error) {
+	var statusErr *fetcher.StatusError
+
+	doc, err := f.GetDocument(ctx, url)
+
+	if errors.As(err, &statusErr) && (statusErr.StatusCode == http.StatusNotFound || statusErr.StatusCode == http.StatusGone) {
+		return models.StatusCancelled, nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if ld := findJSONLDEvent(doc); ld != nil {
+		if status := jsonLDStatus(ld); status != "" {
+			return status, nil
+		}
+	}
+
+	return pageStatus(doc), nil
+}
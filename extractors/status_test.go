@@ -0,0 +1,40 @@
+package extractors
+
+import (
+	"celeve/models"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestPageStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want models.EventStatus
+	}{
+		{"title prefix", `<h1>CANCELLED: Board meeting</h1>`, models.StatusCancelled},
+		{"bracketed title prefix", `<h1>[Postponed] Summer picnic</h1>`, models.StatusPostponed},
+		{"status word inside title", `<h1>The Cancelled Podcast Live</h1>`, ""},
+		{"alert", `<h1>Board meeting</h1><div role="alert">This event has been cancelled.</div>`, models.StatusCancelled},
+		{"alert leading status", `<h1>Board meeting</h1><div role="alert">Rescheduled to June 3</div>`, models.StatusRescheduled},
+		{"policy banner", `<h1>Board meeting</h1><div class="banner">Tickets cancelled less than 24 hours ahead are not refunded.</div>`, ""},
+		{"policy alert", `<h1>Board meeting</h1><div role="alert">See our cancellation policy. Orders cancelled within 24 hours are refunded.</div>`, ""},
+		{"status class", `<h1>Board meeting</h1><p class="event-cancelled-notice">Canceled</p>`, models.StatusCancelled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.html))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := pageStatus(doc); got != tt.want {
+				t.Errorf("pageStatus = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
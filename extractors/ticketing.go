@@ -0,0 +1,139 @@
+package extractors
+
+import (
+	"celeve/models"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var pricePattern = regexp.MustCompile(`(?i)(?:((?:US|CA|C|AU|A|NZ)?\$|[€£¥])\s?(\d[\d,]*(?:\.\d{1,2})?)|(\d[\d,]*(?:\.\d{1,2})?)\s?(USD|EUR|GBP|CAD|AUD|NZD|JPY))`)
+
+// freePattern matches a line that says the event is free, such as "Free" or
+// "Admission: Free", but not one that offers something free, like "Free
+// parking".
+var freePattern = regexp.MustCompile(`(?im)^\s*(?:(?:price|cost|tickets?|admission|entry)\s*:?\s*)?free(?:\s+(?:admission|entry|event|tickets?|registration|to attend))?\s*[.!]?\s*$`)
+
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// currencySymbols are the symbols that only one currency uses. A bare "$" or
+// "¥" takes the currency the page names, if any.
+var currencySymbols = map[string]string{
+	"US$": "USD",
+	"CA$": "CAD",
+	"C$":  "CAD",
+	"AU$": "AUD",
+	"A$":  "AUD",
+	"NZ$": "NZD",
+	"€":   "EUR",
+	"£":   "GBP",
+}
+
+// pageCurrencySelector matches the markup pages use to name the currency of
+// their prices.
+const pageCurrencySelector = `meta[itemprop="priceCurrency"], meta[property="product:price:currency"], meta[property="og:price:currency"]`
+
+// pageCurrencyScript evaluates to the content pageCurrency reads, in a
+// rendered page.
+var pageCurrencyScript = fmt.Sprintf(`document.querySelector(%q)?.content || ''`, pageCurrencySelector)
+
+// pageCurrency returns the currency a page's schema.org offers or meta tags
+// name, or "" if it names none.
+func pageCurrency(doc *goquery.Document) string {
+	if ld := findJSONLDEvent(doc); ld != nil {
+		for _, offer := range ldObjects(ld["offers"]) {
+			if code := currencyCode(ldString(offer["priceCurrency"])); code != "" {
+				return code
+			}
+		}
+	}
+
+	return currencyCode(doc.Find(pageCurrencySelector).First().AttrOr("content", ""))
+}
+
+// currencyCode returns value as an ISO 4217 code, or "" if it isn't one.
+func currencyCode(value string) string {
+	value = strings.ToUpper(strings.TrimSpace(value))
+
+	if currencyCodePattern.MatchString(value) {
+		return value
+	}
+
+	return ""
+}
+
+// ticketingFromText reads ticketing from the price text shown on event pages,
+// such as "Free", "$25 – $40" or "Sold Out". Prices in dollars or yen are
+// taken to be in currency, the one the page names; without it the currency
+// is left unknown.
+func ticketingFromText(text, currency string) models.Ticketing {
+	var ticketing models.Ticketing
+
+	lower := strings.ToLower(text)
+	ticketing.SoldOut = strings.Contains(lower, "sold out")
+
+	for _, match := range pricePattern.FindAllStringSubmatch(text, -1) {
+		symbol, amount := match[1], match[2]
+
+		if symbol == "" {
+			amount = match[3]
+			ticketing.Currency = strings.ToUpper(match[4])
+		} else if code, ok := currencySymbols[strings.ToUpper(symbol)]; ok {
+			ticketing.Currency = code
+		} else {
+			ticketing.Currency = currencyCode(currency)
+		}
+
+		price, err := strconv.ParseFloat(strings.ReplaceAll(amount, ",", ""), 64)
+
+		if err != nil {
+			continue
+		}
+
+		setPrice(&ticketing, price)
+	}
+
+	if ticketing.Price == nil && freePattern.MatchString(text) {
+		setPrice(&ticketing, 0)
+	}
+
+	return ticketing
+}
+
+// pageTicketing reads the price text of an event page whose tickets are bought
+// on the page itself.
+func pageTicketing(text, currency, url string) models.Ticketing {
+	ticketing := ticketingFromText(text, currency)
+
+	if !ticketing.IsZero() {
+		ticketing.URL = url
+	}
+
+	return ticketing
+}
+
+// setPrice widens the ticketing's price range to include price.
+func setPrice(ticketing *models.Ticketing, price float64) {
+	low, high := price, price
+
+	if ticketing.Price != nil {
+		low = min(low, *ticketing.Price)
+		high = max(high, *ticketing.Price)
+	}
+
+	if ticketing.MaxPrice != nil {
+		high = max(high, *ticketing.MaxPrice)
+	}
+
+	ticketing.Price = &low
+	ticketing.MaxPrice = nil
+
+	if high > low {
+		ticketing.MaxPrice = &high
+	}
+
+	ticketing.Free = high == 0
+}
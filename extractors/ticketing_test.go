@@ -0,0 +1,50 @@
+package extractors
+
+import "testing"
+
+func TestTicketingFromText(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		currency string
+		price    float64
+		maxPrice float64
+		code     string
+		free     bool
+		soldOut  bool
+		known    bool
+	}{
+		{name: "free label", text: "Free\nRegister", price: 0, free: true, known: true},
+		{name: "admission free", text: "Admission: Free", price: 0, free: true, known: true},
+		{name: "free parking", text: "Register now\nFree parking on site"},
+		{name: "free drinks", text: "Join us for free drinks and networking"},
+		{name: "bare dollars", text: "$25", price: 25, known: true},
+		{name: "bare dollars with page currency", text: "$25", currency: "cad", price: 25, code: "CAD", known: true},
+		{name: "prefixed dollars", text: "CA$25 – CA$40", currency: "USD", price: 25, maxPrice: 40, code: "CAD", known: true},
+		{name: "code after amount", text: "From 1,200 JPY", price: 1200, code: "JPY", known: true},
+		{name: "euros", text: "€10", price: 10, code: "EUR", known: true},
+		{name: "sold out", text: "£15 Sold Out", price: 15, code: "GBP", soldOut: true, known: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ticketingFromText(tt.text, tt.currency)
+
+			if (got.Price != nil) != tt.known {
+				t.Fatalf("price = %v, want known %v", got.Price, tt.known)
+			}
+
+			if got.Price != nil && *got.Price != tt.price {
+				t.Errorf("price = %v, want %v", *got.Price, tt.price)
+			}
+
+			if tt.maxPrice != 0 && (got.MaxPrice == nil || *got.MaxPrice != tt.maxPrice) {
+				t.Errorf("max price = %v, want %v", got.MaxPrice, tt.maxPrice)
+			}
+
+			if got.Currency != tt.code || got.Free != tt.free || got.SoldOut != tt.soldOut {
+				t.Errorf("got currency %q, free %v, sold out %v; want %q, %v, %v", got.Currency, got.Free, got.SoldOut, tt.code, tt.free, tt.soldOut)
+			}
+		})
+	}
+}
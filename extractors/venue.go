@@ -0,0 +1,59 @@
+package extractors
+
+import (
+	"celeve/models"
+	"regexp"
+	"strings"
+)
+
+var regionPostalPattern = regexp.MustCompile(`^([A-Za-z .]+?)\s+(\d{5}(-\d{4})?|[A-Z]\d[A-Z] ?\d[A-Z]\d)$`)
+
+// VenueFromText builds a venue from a name and a free-form address as shown
+// on event pages, such as "1 Main St\nNew York, NY 10001". The last line is
+// read as city, region and postal code; everything before it is the street.
+func VenueFromText(name, address string) models.Venue {
+	venue := models.Venue{Name: strings.TrimSpace(name)}
+
+	var lines []string
+
+	for _, line := range strings.FieldsFunc(address, func(r rune) bool { return r == '\n' }) {
+		if line = strings.TrimSpace(line); line != "" && line != venue.Name {
+			lines = append(lines, line)
+		}
+	}
+
+	if len(lines) == 0 {
+		return venue
+	}
+
+	// A single line is usually "street, city, region".
+	if len(lines) == 1 {
+		lines = strings.Split(lines[0], ", ")
+
+		if len(lines) < 3 {
+			venue.StreetAddress = strings.Join(lines, ", ")
+			return venue
+		}
+
+		lines = []string{strings.Join(lines[:len(lines)-2], ", "), strings.Join(lines[len(lines)-2:], ", ")}
+	}
+
+	venue.StreetAddress = strings.Join(lines[:len(lines)-1], ", ")
+	parts := strings.Split(lines[len(lines)-1], ", ")
+	venue.City = parts[0]
+
+	if len(parts) > 1 {
+		venue.Region = parts[1]
+
+		if match := regionPostalPattern.FindStringSubmatch(parts[1]); match != nil {
+			venue.Region = match[1]
+			venue.PostalCode = match[2]
+		}
+	}
+
+	if len(parts) > 2 {
+		venue.Country = parts[2]
+	}
+
+	return venue
+}
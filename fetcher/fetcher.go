@@ -0,0 +1,124 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const requestTimeout = 30 * time.Second
+const maxBodySize = 10 << 20
+
+// StatusError is returned when a page answers with a non-2xx status.
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("GET %s returned %d", e.URL, e.StatusCode)
+}
+
+// Fetcher downloads pages over plain HTTP with the configured user agent and
+// proxy. Many event pages are server-rendered, so this is tried before paying
+// for a headless browser.
+type Fetcher struct {
+	client    *http.Client
+	userAgent string
+}
+
+func New(userAgent, proxy string) (*Fetcher, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy %s: %w", proxy, err)
+		}
+
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	return &Fetcher{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   requestTimeout,
+		},
+		userAgent: userAgent,
+	}, nil
+}
+
+// GetHTML returns the raw body of url.
+func (f *Fetcher) GetHTML(ctx context.Context, url string) (string, error) {
+	return f.get(ctx, url, "text/html,application/xhtml+xml")
+}
+
+// GetFeed returns the raw body of a calendar or news feed. file:// URLs are
+// read from disk, which is handy for local exports and fixtures.
+func (f *Fetcher) GetFeed(ctx context.Context, feedURL string) (string, error) {
+	if path, ok := strings.CutPrefix(feedURL, "file://"); ok {
+		body, err := os.ReadFile(path)
+
+		return string(body), err
+	}
+
+	return f.get(ctx, feedURL, "text/calendar,application/rss+xml,application/atom+xml,application/xml;q=0.9,*/*;q=0.8")
+}
+
+// GetImage returns the raw bytes of an image.
+func (f *Fetcher) GetImage(ctx context.Context, imageURL string) ([]byte, error) {
+	body, err := f.get(ctx, imageURL, "image/avif,image/webp,image/png,image/jpeg,image/*;q=0.8")
+
+	return []byte(body), err
+}
+
+func (f *Fetcher) get(ctx context.Context, url, accept string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("User-Agent", f.userAgent)
+	req.Header.Set("Accept", accept)
+	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+
+	resp, err := f.client.Do(req)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", &StatusError{URL: url, StatusCode: resp.StatusCode}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(body), nil
+}
+
+// GetDocument returns url parsed for querying with CSS selectors.
+func (f *Fetcher) GetDocument(ctx context.Context, url string) (*goquery.Document, error) {
+	body, err := f.GetHTML(ctx, url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return goquery.NewDocumentFromReader(strings.NewReader(body))
+}
@@ -2,25 +2,73 @@ package gateways
 
 import (
 	"celeve/config"
+	"celeve/geocoder"
 	"celeve/models"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"strconv"
+	"slices"
 	"strings"
 	"time"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 	"github.com/rs/zerolog/log"
 )
 
 type SqliteGateway interface {
 	UpsertEvent(models.CalendarEvent) error
-	GetEvents(start, end time.Time, limit, offset int, tags []string) ([]models.CalendarEvent, error)
+	GetEvents(filter EventFilter) ([]models.CalendarEvent, error)
 	GetEvent(id string) (*models.CalendarEvent, error)
+	GetEventRevisions(id string) ([]models.EventRevision, error)
 	GetEventsForProcessing() ([]*models.CalendarEvent, error)
+	GetEventsForStatusCheck(checkedBefore time.Time, limit int) ([]*models.CalendarEvent, error)
+	GetEventsForDedup(since time.Time) ([]*models.CalendarEvent, error)
+	SetCanonicalIDs(canonicalIDs map[string]string) error
+	UpdateStatus(id string, status models.EventStatus, checkedAt time.Time) error
 	BulkProcessEvents(events []*models.CalendarEvent) error
 	GetTags() ([]string, error)
+	Close() error
+}
+
+// sqliteDriver is go-sqlite3 with the extra SQL functions the queries use.
+const sqliteDriver = "sqlite3_celeve"
+
+// distanceExpr is the distance in km from the venue to a point given as two
+// parameters, or NULL for venues without coordinates.
+const distanceExpr = `CASE WHEN VenueLatitude IS NULL OR VenueLongitude IS NULL THEN NULL
+	ELSE distance_km(VenueLatitude, VenueLongitude, ?, ?) END`
+
+const eventColumns = `ID, Source, SourceID, ContentHash, Name, StartTime, EndTime, Location, Description, OriginURL, Processed, Relevant, Metadata,
+	VenueName, VenueStreetAddress, VenueCity, VenueRegion, VenuePostalCode, VenueCountry, VenueLatitude, VenueLongitude, VenueOnline,
+	TicketPrice, TicketMaxPrice, TicketCurrency, TicketFree, TicketSoldOut, TicketURL,
+	ImageURL, Thumbnail, Status, CanonicalID`
+
+// selectColumns are eventColumns followed by the event's tags, in order, as
+// a JSON array.
+const selectColumns = eventColumns + `,
+	(SELECT json_group_array(tags.Name ORDER BY event_tags.Position)
+		FROM event_tags JOIN tags ON tags.ID = event_tags.TagID
+		WHERE event_tags.EventID = calendar_events.ID)`
+
+// tagFilter keeps events that have every one of a number of tags, given as
+// parameters, followed by that number.
+const tagFilter = `ID IN (SELECT event_tags.EventID
+	FROM event_tags JOIN tags ON tags.ID = event_tags.TagID
+	WHERE tags.Name IN (%s)
+	GROUP BY event_tags.EventID
+	HAVING COUNT(*) = ?)`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func init() {
+	sql.Register(sqliteDriver, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return conn.RegisterFunc("distance_km", geocoder.DistanceKm, true)
+		},
+	})
 }
 
 type sqliteGateway struct {
@@ -28,155 +76,458 @@ type sqliteGateway struct {
 }
 
 func NewEventSqliteGateway() (SqliteGateway, error) {
-	db, err := sql.Open("sqlite3", config.Get().EventStorePath)
+	db, err := openEventStore()
 
 	if err != nil {
 		return nil, err
 	}
 
-	query := `
-	CREATE TABLE IF NOT EXISTS calendar_events (
-		ID TEXT PRIMARY KEY,
-		Name TEXT,
-		StartTime DATETIME,
-		EndTime DATETIME,
-		Location TEXT,
-		Description TEXT,
-		OriginURL TEXT,
-		Tags TEXT,
-		Processed BOOLEAN,
-		Relevant BOOLEAN,
-		Metadata TEXT
-	);
-	`
-	if _, err := db.Exec(query); err != nil {
-		return nil, err
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrating %s: %w", config.Get().EventStorePath, err)
 	}
 
 	return &sqliteGateway{db: db}, nil
 }
 
-func (s *sqliteGateway) UpsertEvent(event models.CalendarEvent) error {
-	query := `
-	INSERT OR IGNORE INTO calendar_events (ID, Name, StartTime, EndTime, Location, Description, OriginURL, Tags, Processed, Relevant, Metadata)
-	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
-	`
-	tags := strings.Trim(strings.Join(event.Tags, ","), ",")
+func openEventStore() (*sql.DB, error) {
+	return sql.Open(sqliteDriver, config.Get().EventStorePath)
+}
+
+func (s *sqliteGateway) Close() error {
+	return s.db.Close()
+}
 
+// UpsertEvent stores an event, or updates the stored copy with what its
+// source now says. Fields the processor sets are kept, and changes to the
+// fields the source sets are recorded in event_revisions.
+func (s *sqliteGateway) UpsertEvent(event models.CalendarEvent) error {
 	log.Info().Msgf("Saving event: %s", event.ID)
 
+	tx, err := s.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	revision := models.EventRevision{
+		EventID:     event.ID,
+		Kind:        models.RevisionCreated,
+		RecordedAt:  time.Now(),
+		ContentHash: event.ContentHash,
+	}
+	stored, err := scanEvent(tx.QueryRow("SELECT "+selectColumns+" FROM calendar_events WHERE ID = ?", event.ID))
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		event.Processed = false
+		event.Relevant = false
+	case err != nil:
+		return err
+	default:
+		event = mergeEvent(stored, event)
+		revision.Kind = models.RevisionUpdated
+		revision.Changes = models.DiffEvents(stored, event)
+	}
+
+	if err := writeEvent(tx, event); err != nil {
+		return err
+	}
+
+	if revision.Kind == models.RevisionCreated || len(revision.Changes) > 0 {
+		changes, err := json.Marshal(revision.Changes)
+
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(
+			"INSERT INTO event_revisions (EventID, Kind, RecordedAt, ContentHash, Changes) VALUES (?, ?, ?, ?, ?)",
+			revision.EventID,
+			revision.Kind,
+			revision.RecordedAt,
+			revision.ContentHash,
+			string(changes),
+		); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// mergeEvent takes what the source says from crawled and what the processor
+// worked out from stored. Tags the source adds are kept alongside the ones
+// the processor found. When the source changes what the processor worked
+// from, its results are dropped and the event is processed again.
+func mergeEvent(stored, crawled models.CalendarEvent) models.CalendarEvent {
+	merged := crawled
+	merged.Tags = slices.Clone(stored.Tags)
+
+	for _, tag := range crawled.Tags {
+		if !slices.Contains(merged.Tags, tag) {
+			merged.Tags = append(merged.Tags, tag)
+		}
+	}
+
+	merged.Processed = stored.Processed
+	merged.Relevant = stored.Relevant
+	merged.Thumbnail = stored.Thumbnail
+	merged.CanonicalID = stored.CanonicalID
+
+	if merged.Venue.Latitude == nil {
+		merged.Venue.Latitude = stored.Venue.Latitude
+		merged.Venue.Longitude = stored.Venue.Longitude
+	}
+
+	changes := models.DiffEvents(stored, merged)
+	reprocess := false
+
+	if _, ok := changes["Venue"]; ok && crawled.Venue.Latitude == nil {
+		merged.Venue.Latitude = nil
+		merged.Venue.Longitude = nil
+		reprocess = true
+	}
+
+	if _, ok := changes["ImageURL"]; ok {
+		merged.Thumbnail = ""
+		reprocess = true
+	}
+
+	_, renamed := changes["Name"]
+	_, redescribed := changes["Description"]
+
+	// The processor tags an event and decides its relevance from the tags it
+	// has, so it starts again from the source's.
+	if reprocess || renamed || redescribed {
+		merged.Tags = slices.Clone(crawled.Tags)
+		merged.Relevant = false
+		merged.Processed = false
+	}
+
+	return merged
+}
+
+// writeEvent inserts event, or overwrites every column of the stored copy.
+func writeEvent(tx *sql.Tx, event models.CalendarEvent) error {
+	columns := strings.Split(eventColumns, ",")
+	placeholders := make([]string, len(columns))
+	var assignments []string
+
+	for i, column := range columns {
+		column = strings.TrimSpace(column)
+		placeholders[i] = "?"
+
+		if column != "ID" {
+			assignments = append(assignments, fmt.Sprintf("%[1]s = excluded.%[1]s", column))
+		}
+	}
+
+	query := fmt.Sprintf(
+		"INSERT INTO calendar_events (%s) VALUES (%s) ON CONFLICT(ID) DO UPDATE SET %s",
+		eventColumns,
+		strings.Join(placeholders, ", "),
+		strings.Join(assignments, ", "),
+	)
 	meta, err := json.Marshal(event.Metadata)
 
 	if err != nil {
 		return err
 	}
 
-	_, err = s.db.Exec(
+	_, err = tx.Exec(
 		query,
 		event.ID,
+		event.Source,
+		event.SourceID,
+		event.ContentHash,
 		event.Name,
 		event.StartTime,
 		event.EndTime,
 		event.Location,
 		event.Description,
 		event.OriginURL,
-		tags,
-		false,
-		false,
+		event.Processed,
+		event.Relevant,
 		string(meta),
+		event.Venue.Name,
+		event.Venue.StreetAddress,
+		event.Venue.City,
+		event.Venue.Region,
+		event.Venue.PostalCode,
+		event.Venue.Country,
+		event.Venue.Latitude,
+		event.Venue.Longitude,
+		event.Venue.Online,
+		event.Ticketing.Price,
+		event.Ticketing.MaxPrice,
+		event.Ticketing.Currency,
+		event.Ticketing.Free,
+		event.Ticketing.SoldOut,
+		event.Ticketing.URL,
+		event.ImageURL,
+		event.Thumbnail,
+		event.Status,
+		event.CanonicalID,
 	)
 
-	return err
+	if err != nil {
+		return err
+	}
+
+	return setTags(tx, event.ID, event.Tags)
 }
 
+// setTags replaces an event's tags, adding any tag not seen before. Empty
+// and repeated tags are left out.
+func setTags(tx *sql.Tx, eventID string, tags []string) error {
+	if _, err := tx.Exec("DELETE FROM event_tags WHERE EventID = ?", eventID); err != nil {
+		return err
+	}
+
+	var seen []string
+
+	for _, tag := range tags {
+		if tag = strings.TrimSpace(tag); tag == "" || slices.Contains(seen, tag) {
+			continue
+		}
+
+		if _, err := tx.Exec("INSERT INTO tags (Name) VALUES (?) ON CONFLICT (Name) DO NOTHING", tag); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(
+			"INSERT INTO event_tags (EventID, TagID, Position) SELECT ?, ID, ? FROM tags WHERE Name = ?",
+			eventID,
+			len(seen),
+			tag,
+		); err != nil {
+			return err
+		}
+
+		seen = append(seen, tag)
+	}
+
+	return nil
+}
+
+// GetEventRevisions returns the changes recorded for an event, oldest first.
+func (s *sqliteGateway) GetEventRevisions(id string) ([]models.EventRevision, error) {
+	rows, err := s.db.Query(
+		"SELECT EventID, Kind, RecordedAt, ContentHash, Changes FROM event_revisions WHERE EventID = ? ORDER BY RecordedAt, ID",
+		id,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	revisions := make([]models.EventRevision, 0)
+
+	for rows.Next() {
+		var revision models.EventRevision
+		var changes string
+
+		if err := rows.Scan(&revision.EventID, &revision.Kind, &revision.RecordedAt, &revision.ContentHash, &changes); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal([]byte(changes), &revision.Changes); err != nil {
+			return nil, err
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, rows.Err()
+}
+
+// BulkProcessEvents stores what the processor worked out for each event.
 func (s *sqliteGateway) BulkProcessEvents(events []*models.CalendarEvent) error {
-	queryTemplate := `
+	tx, err := s.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
 		UPDATE calendar_events
-		SET
-			Tags = CASE id
-				%s
-			END,
-			Relevant = CASE id
-				%s
-			END,
-			Processed = CASE id
-				%s
-			END
-		WHERE id IN (%s)
-	`
-	var ids []string
-	var tags []string
-	var relevants []string
-	var processeds []string
-	caseTemplate := "WHEN '%s' THEN '%s'"
-	boolCaseTemplate := "WHEN '%s' THEN %s"
+		SET Relevant = ?, Processed = ?, VenueLatitude = ?, VenueLongitude = ?, Thumbnail = ?
+		WHERE ID = ?
+	`)
 
-	for _, event := range events {
-		ids = append(
-			ids,
-			fmt.Sprintf("'%s'", event.ID),
-		)
-		tags = append(
-			tags,
-			fmt.Sprintf(caseTemplate, event.ID, strings.Join(event.Tags, ",")),
-		)
-		relevants = append(
-			relevants,
-			fmt.Sprintf(boolCaseTemplate, event.ID, strconv.FormatBool(event.Relevant)),
-		)
-		processeds = append(
-			processeds,
-			fmt.Sprintf(boolCaseTemplate, event.ID, strconv.FormatBool(event.Processed)),
-		)
+	if err != nil {
+		return err
 	}
 
-	query := fmt.Sprintf(
-		queryTemplate,
-		strings.Join(tags, "\n"),
-		strings.Join(relevants, "\n"),
-		strings.Join(processeds, "\n"),
-		strings.Join(ids, ", "),
-	)
+	defer stmt.Close()
+
+	for _, event := range events {
+		if _, err := stmt.Exec(
+			event.Relevant,
+			event.Processed,
+			event.Venue.Latitude,
+			event.Venue.Longitude,
+			event.Thumbnail,
+			event.ID,
+		); err != nil {
+			return err
+		}
 
-	_, err := s.db.Exec(query)
+		if err := setTags(tx, event.ID, event.Tags); err != nil {
+			return err
+		}
+	}
 
-	return err
+	return tx.Commit()
 }
 
-func (s *sqliteGateway) GetEvents(start, end time.Time, limit, offset int, tags []string) ([]models.CalendarEvent, error) {
+func (s *sqliteGateway) GetEvents(filter EventFilter) ([]models.CalendarEvent, error) {
 	queryTemplate := `
 		SELECT
-			ID, Name, StartTime, EndTime, Location, Description, OriginURL, Tags, Processed, Relevant, Metadata
+			` + selectColumns + `
 		FROM
 			calendar_events
-		WHERE StartTime BETWEEN ? AND ?
+		WHERE %s
+		%s
+		%s
 		%s
 		LIMIT ? OFFSET ?;
 	`
-	tagsTemplate := `(Tags LIKE '%[1]s,%%' OR Tags LIKE '%%,%[1]s,%%' OR Tags LIKE '%%,%[1]s' OR Tags = '%[1]s')`
+	matchTemplate := `StartTime BETWEEN ? AND ?
+		%s
+		%s`
+	args := []any{filter.Start, filter.End}
 	tagBlock := ""
-	var tagStatements []string
+	var tags []string
 
-	for _, tag := range tags {
-		tagStatements = append(
-			tagStatements,
-			fmt.Sprintf(tagsTemplate, tag),
-		)
+	for _, tag := range filter.Tags {
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+			args = append(args, tag)
+		}
 	}
 
-	if len(tagStatements) > 0 {
-		tagBlock = "AND " + strings.Join(tagStatements, " AND\n")
+	if len(tags) > 0 {
+		tagBlock = "AND " + fmt.Sprintf(tagFilter, "?"+strings.Repeat(", ?", len(tags)-1))
+		args = append(args, len(tags))
 	}
 
-	query := fmt.Sprintf(queryTemplate, tagBlock)
+	nearBlock := ""
+
+	if filter.Near != nil {
+		nearBlock = "AND " + distanceExpr + " <= ?"
+		args = append(args, filter.Near.Latitude, filter.Near.Longitude, filter.Near.RadiusKm)
+	}
+
+	match := fmt.Sprintf(matchTemplate, tagBlock, nearBlock)
+
+	// Any copy of an event may have the tags or the location, but only the
+	// copy that is shown is returned.
+	if !filter.IncludeDuplicates {
+		match = `CanonicalID = '' AND ID IN (
+			SELECT CASE CanonicalID WHEN '' THEN ID ELSE CanonicalID END
+			FROM calendar_events
+			WHERE ` + match + `)`
+	}
+
+	// The status and price shown are the returned copy's, so they're what
+	// has to match.
+	statusBlock := ""
+
+	if len(filter.Statuses) > 0 {
+		statusBlock = "AND Status IN (?" + strings.Repeat(", ?", len(filter.Statuses)-1) + ")"
+
+		for _, status := range filter.Statuses {
+			args = append(args, status)
+		}
+	}
+
+	priceBlock := ""
+
+	if filter.FreeOnly {
+		priceBlock = "AND TicketFree"
+	} else if filter.MaxPrice != nil {
+		priceBlock = "AND (TicketFree OR TicketPrice <= ?)"
+		args = append(args, *filter.MaxPrice)
+	}
+
+	orderBlock := ""
+
+	if filter.Near != nil && filter.SortByDistance {
+		orderBlock = "ORDER BY " + distanceExpr
+		args = append(args, filter.Near.Latitude, filter.Near.Longitude)
+	}
+
+	query := fmt.Sprintf(queryTemplate, match, statusBlock, priceBlock, orderBlock)
+	args = append(args, filter.Limit, filter.Offset)
+	events, err := s.queryMany(query, args...)
+
+	if err != nil {
+		return nil, err
+	}
 
-	return s.queryMany(query, start, end, limit, offset)
+	if err := s.addSourceURLs(events); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// addSourceURLs lists where each event can be found: its own page followed
+// by the pages of its duplicates.
+func (s *sqliteGateway) addSourceURLs(events []models.CalendarEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	index := make(map[string]int, len(events))
+	args := make([]any, len(events))
+
+	for i := range events {
+		events[i].SourceURLs = []string{events[i].OriginURL}
+		index[events[i].ID] = i
+		args[i] = events[i].ID
+	}
+
+	rows, err := s.db.Query(
+		"SELECT CanonicalID, OriginURL FROM calendar_events WHERE CanonicalID IN (?"+strings.Repeat(", ?", len(events)-1)+") ORDER BY StartTime, ID",
+		args...,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var canonicalID, originURL string
+
+		if err := rows.Scan(&canonicalID, &originURL); err != nil {
+			return err
+		}
+
+		event := &events[index[canonicalID]]
+
+		if !slices.Contains(event.SourceURLs, originURL) {
+			event.SourceURLs = append(event.SourceURLs, originURL)
+		}
+	}
+
+	return rows.Err()
 }
 
 func (s *sqliteGateway) GetEventsForProcessing() ([]*models.CalendarEvent, error) {
 	query := `
 		SELECT
-			ID, Name, StartTime, EndTime, Location, Description, OriginURL, Tags, Processed, Relevant, Metadata
+			` + selectColumns + `
 		FROM
 			calendar_events
 		WHERE Processed = FALSE;
@@ -197,79 +548,141 @@ func (s *sqliteGateway) GetEventsForProcessing() ([]*models.CalendarEvent, error
 	return resultPtrs, nil
 }
 
+// GetEventsForStatusCheck returns upcoming events whose status hasn't been
+// checked since checkedBefore, least recently checked first.
+func (s *sqliteGateway) GetEventsForStatusCheck(checkedBefore time.Time, limit int) ([]*models.CalendarEvent, error) {
+	query := `
+		SELECT
+			` + selectColumns + `
+		FROM
+			calendar_events
+		WHERE StartTime > ?
+		AND (StatusCheckedAt IS NULL OR StatusCheckedAt < ?)
+		ORDER BY StatusCheckedAt IS NOT NULL, StatusCheckedAt, StartTime
+		LIMIT ?;
+	`
+	results, err := s.queryMany(query, time.Now(), checkedBefore, limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var resultPtrs []*models.CalendarEvent
+
+	for _, event := range results {
+		resultPtrs = append(resultPtrs, &event)
+	}
+
+	return resultPtrs, nil
+}
+
+// GetEventsForDedup returns the events starting after since, in order of
+// their start time, for the processor to look for duplicates among.
+func (s *sqliteGateway) GetEventsForDedup(since time.Time) ([]*models.CalendarEvent, error) {
+	query := `
+		SELECT
+			` + selectColumns + `
+		FROM
+			calendar_events
+		WHERE StartTime > ?
+		ORDER BY StartTime, ID;
+	`
+	results, err := s.queryMany(query, since)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var resultPtrs []*models.CalendarEvent
+
+	for _, event := range results {
+		resultPtrs = append(resultPtrs, &event)
+	}
+
+	return resultPtrs, nil
+}
+
+// SetCanonicalIDs points each event ID in canonicalIDs at the event it
+// duplicates, or at none for an empty string.
+func (s *sqliteGateway) SetCanonicalIDs(canonicalIDs map[string]string) error {
+	tx, err := s.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("UPDATE calendar_events SET CanonicalID = ? WHERE ID = ?")
+
+	if err != nil {
+		return err
+	}
+
+	defer stmt.Close()
+
+	for id, canonicalID := range canonicalIDs {
+		if _, err := stmt.Exec(canonicalID, id); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *sqliteGateway) UpdateStatus(id string, status models.EventStatus, checkedAt time.Time) error {
+	_, err := s.db.Exec(
+		"UPDATE calendar_events SET Status = ?, StatusCheckedAt = ? WHERE ID = ?",
+		status,
+		checkedAt,
+		id,
+	)
+
+	return err
+}
+
 func (s *sqliteGateway) GetEvent(id string) (*models.CalendarEvent, error) {
 	query := `
 		SELECT
-			ID, Name, StartTime, EndTime, Location, Description, OriginURL, Tags, Processed, Relevant, Metadata
+			` + selectColumns + `
 		FROM
 			calendar_events
 		WHERE ID = ?
 		LIMIT 1
 	`
-	var event models.CalendarEvent
-	var tags string
-	var rawMeta string
-
-	row := s.db.QueryRow(query, id)
-	err := row.Scan(
-		&event.ID,
-		&event.Name,
-		&event.StartTime,
-		&event.EndTime,
-		&event.Location,
-		&event.Description,
-		&event.OriginURL,
-		&tags,
-		&event.Processed,
-		&event.Relevant,
-		&rawMeta,
-	)
+	event, err := scanEvent(s.db.QueryRow(query, id))
 
 	if err != nil {
 		return nil, err
 	}
 
-	if err = json.Unmarshal([]byte(rawMeta), &event.Metadata); err != nil {
+	events := []models.CalendarEvent{event}
+
+	if err := s.addSourceURLs(events); err != nil {
 		return nil, err
 	}
 
-	event.Tags = strings.Split(strings.Trim(tags, ","), ",")
-
-	return &event, nil
+	return &events[0], nil
 }
 
+// GetTags returns the tags of upcoming events, in alphabetical order.
 func (s *sqliteGateway) GetTags() ([]string, error) {
 	query := `
-		WITH RECURSIVE split_string AS (
-			SELECT 
-				substr(Tags, 1, instr(Tags || ',', ',') - 1) AS part,
-				substr(Tags, instr(Tags || ',', ',') + 1) AS rest,
-				StartTime
-			FROM calendar_events
-			WHERE StartTime > ?
-			UNION ALL
-			SELECT 
-				substr(rest, 1, instr(rest || ',', ',') - 1),
-				substr(rest, instr(rest || ',', ',') + 1),
-				StartTime
-			FROM split_string
-			WHERE rest != '' AND StartTime > ?
-		),
-		deduplicated_tags AS (
-			SELECT DISTINCT part FROM split_string
-		)
-		SELECT part
-		FROM deduplicated_tags
-		WHERE part != "";
+		SELECT DISTINCT tags.Name
+		FROM tags
+		JOIN event_tags ON event_tags.TagID = tags.ID
+		JOIN calendar_events ON calendar_events.ID = event_tags.EventID
+		WHERE calendar_events.StartTime > ?
+		ORDER BY tags.Name;
 	`
-
-	t := time.Now()
-	rows, err := s.db.Query(query, t, t)
+	rows, err := s.db.Query(query, time.Now())
 
 	if err != nil {
 		return nil, err
 	}
 
+	defer rows.Close()
+
 	var tags []string
 
 	for rows.Next() {
@@ -282,7 +695,7 @@ func (s *sqliteGateway) GetTags() ([]string, error) {
 		tags = append(tags, tag)
 	}
 
-	return tags, nil
+	return tags, rows.Err()
 }
 
 func (s *sqliteGateway) queryMany(query string, args ...any) ([]models.CalendarEvent, error) {
@@ -295,40 +708,86 @@ func (s *sqliteGateway) queryMany(query string, args ...any) ([]models.CalendarE
 	var events []models.CalendarEvent
 
 	for rows.Next() {
-		var event models.CalendarEvent
-		var tags string
-		var rawMeta string
-
-		err := rows.Scan(
-			&event.ID,
-			&event.Name,
-			&event.StartTime,
-			&event.EndTime,
-			&event.Location,
-			&event.Description,
-			&event.OriginURL,
-			&tags,
-			&event.Processed,
-			&event.Relevant,
-			&rawMeta,
-		)
+		event, err := scanEvent(rows)
 
 		if err != nil {
 			return nil, err
 		}
 
-		if err = json.Unmarshal([]byte(rawMeta), &event.Metadata); err != nil {
-			return nil, err
-		}
-
-		if tags != "" {
-			event.Tags = strings.Split(strings.Trim(tags, ","), ",")
-		} else {
-			event.Tags = make([]string, 0)
-		}
-
 		events = append(events, event)
 	}
 
 	return events, nil
 }
+
+// scanEvent reads a row selected with selectColumns.
+func scanEvent(row rowScanner) (models.CalendarEvent, error) {
+	var event models.CalendarEvent
+	var tags string
+	var rawMeta string
+	var latitude, longitude sql.NullFloat64
+	var price, maxPrice sql.NullFloat64
+
+	err := row.Scan(
+		&event.ID,
+		&event.Source,
+		&event.SourceID,
+		&event.ContentHash,
+		&event.Name,
+		&event.StartTime,
+		&event.EndTime,
+		&event.Location,
+		&event.Description,
+		&event.OriginURL,
+		&event.Processed,
+		&event.Relevant,
+		&rawMeta,
+		&event.Venue.Name,
+		&event.Venue.StreetAddress,
+		&event.Venue.City,
+		&event.Venue.Region,
+		&event.Venue.PostalCode,
+		&event.Venue.Country,
+		&latitude,
+		&longitude,
+		&event.Venue.Online,
+		&price,
+		&maxPrice,
+		&event.Ticketing.Currency,
+		&event.Ticketing.Free,
+		&event.Ticketing.SoldOut,
+		&event.Ticketing.URL,
+		&event.ImageURL,
+		&event.Thumbnail,
+		&event.Status,
+		&event.CanonicalID,
+		&tags,
+	)
+
+	if err != nil {
+		return event, err
+	}
+
+	if err = json.Unmarshal([]byte(rawMeta), &event.Metadata); err != nil {
+		return event, err
+	}
+
+	if latitude.Valid && longitude.Valid {
+		event.Venue.Latitude = &latitude.Float64
+		event.Venue.Longitude = &longitude.Float64
+	}
+
+	if price.Valid {
+		event.Ticketing.Price = &price.Float64
+	}
+
+	if maxPrice.Valid {
+		event.Ticketing.MaxPrice = &maxPrice.Float64
+	}
+
+	if err = json.Unmarshal([]byte(tags), &event.Tags); err != nil {
+		return event, err
+	}
+
+	return event, nil
+}
@@ -0,0 +1,89 @@
+package gateways
+
+import (
+	"celeve/models"
+	"slices"
+	"testing"
+)
+
+func TestMergeEvent(t *testing.T) {
+	latitude, longitude := 40.7, -74.0
+	stored := models.CalendarEvent{
+		Name:        "Hack night",
+		Description: "Bring a laptop",
+		ImageURL:    "https://example.com/a.png",
+		Thumbnail:   "a.jpg",
+		Venue:       models.Venue{Name: "The Hall", City: "New York", Latitude: &latitude, Longitude: &longitude},
+		Tags:        []string{"meetup", "golang"},
+		Processed:   true,
+		Relevant:    true,
+	}
+
+	tests := []struct {
+		name          string
+		change        func(e *models.CalendarEvent)
+		wantProcessed bool
+		wantThumbnail string
+		wantLocated   bool
+		wantTags      []string
+	}{
+		{
+			name:          "unchanged",
+			change:        func(e *models.CalendarEvent) {},
+			wantProcessed: true,
+			wantThumbnail: "a.jpg",
+			wantLocated:   true,
+			wantTags:      []string{"meetup", "golang"},
+		},
+		{
+			name:          "new venue",
+			change:        func(e *models.CalendarEvent) { e.Venue.Name = "The Annex" },
+			wantThumbnail: "a.jpg",
+			wantTags:      []string{"meetup"},
+		},
+		{
+			name:        "new image",
+			change:      func(e *models.CalendarEvent) { e.ImageURL = "https://example.com/b.png" },
+			wantLocated: true,
+			wantTags:    []string{"meetup"},
+		},
+		{
+			name:          "new description",
+			change:        func(e *models.CalendarEvent) { e.Description = "Bring a snack" },
+			wantThumbnail: "a.jpg",
+			wantLocated:   true,
+			wantTags:      []string{"meetup"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			crawled := stored
+			crawled.Venue.Latitude, crawled.Venue.Longitude = nil, nil
+			crawled.Thumbnail = ""
+			crawled.Tags = []string{"meetup"}
+			crawled.Processed, crawled.Relevant = false, false
+			tt.change(&crawled)
+
+			merged := mergeEvent(stored, crawled)
+
+			// Relevance is decided along with the tags, so it's kept only as
+			// long as the event needn't be processed again.
+			if merged.Processed != tt.wantProcessed || merged.Relevant != tt.wantProcessed {
+				t.Errorf("Processed, Relevant = %v, %v, want %v", merged.Processed, merged.Relevant, tt.wantProcessed)
+			}
+
+			if merged.Thumbnail != tt.wantThumbnail {
+				t.Errorf("Thumbnail = %q, want %q", merged.Thumbnail, tt.wantThumbnail)
+			}
+
+			if located := merged.Venue.Latitude != nil; located != tt.wantLocated {
+				t.Errorf("has coordinates = %v, want %v", located, tt.wantLocated)
+			}
+
+			if !slices.Equal(merged.Tags, tt.wantTags) {
+				t.Errorf("Tags = %v, want %v", merged.Tags, tt.wantTags)
+			}
+		})
+	}
+}
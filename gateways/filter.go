@@ -0,0 +1,39 @@
+package gateways
+
+import (
+	"celeve/models"
+	"time"
+)
+
+// EventFilter selects the events returned by GetEvents. Events starting
+// between Start and End are returned; every other field is optional.
+type EventFilter struct {
+	Start  time.Time
+	End    time.Time
+	Limit  int
+	Offset int
+	Tags   []string
+	// Statuses keeps only events with one of these statuses.
+	Statuses []models.EventStatus
+	// FreeOnly keeps only events known to be free.
+	FreeOnly bool
+	// MaxPrice keeps free events and those whose cheapest ticket costs at
+	// most this much, in whatever currency the source used. Events without a
+	// known price are left out.
+	MaxPrice *float64
+	// IncludeDuplicates also returns events that another source's copy is
+	// shown in place of. See CalendarEvent.CanonicalID. Otherwise an event
+	// is returned, as the copy that is shown, when any copy of it has the
+	// tags and location and the shown copy has the status and price.
+	IncludeDuplicates bool
+	// Near keeps only events whose venue is within RadiusKm of a point.
+	Near *GeoFilter
+	// SortByDistance orders results nearest first. It requires Near.
+	SortByDistance bool
+}
+
+type GeoFilter struct {
+	Latitude  float64
+	Longitude float64
+	RadiusKm  float64
+}
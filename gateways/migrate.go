@@ -0,0 +1,249 @@
+package gateways
+
+import (
+	"celeve/config"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+//go:embed migrations/*.sql
+var sqlMigrations embed.FS
+
+// sqlMigrationPattern matches migration file names such as
+// 0001_create_calendar_events.sql.
+var sqlMigrationPattern = regexp.MustCompile(`^(\d+)_(\w+)\.sql$`)
+
+// migration changes the schema from the previous version to this one. Each
+// is applied in a transaction of its own, together with the row recording it
+// in schema_migrations, so a failed migration leaves nothing behind.
+type migration struct {
+	version int
+	name    string
+	up      func(tx *sql.Tx) error
+}
+
+// MigrationStatus is one migration and, if it has run, when.
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+const createSchemaMigrations = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		Version INTEGER PRIMARY KEY,
+		Name TEXT NOT NULL,
+		AppliedAt DATETIME NOT NULL
+	);
+`
+
+// loadMigrations returns the embedded SQL migrations and goMigrations in
+// order of version.
+func loadMigrations() ([]migration, error) {
+	migrations := slices.Clone(goMigrations)
+	names, err := fs.Glob(sqlMigrations, "migrations/*.sql")
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		m := sqlMigrationPattern.FindStringSubmatch(path.Base(name))
+
+		if m == nil {
+			return nil, fmt.Errorf("migration %s isn't named version_name.sql", name)
+		}
+
+		body, err := sqlMigrations.ReadFile(name)
+
+		if err != nil {
+			return nil, err
+		}
+
+		version, _ := strconv.Atoi(m[1])
+		migrations = append(migrations, migration{
+			version: version,
+			name:    m[2],
+			up: func(tx *sql.Tx) error {
+				_, err := tx.Exec(string(body))
+				return err
+			},
+		})
+	}
+
+	slices.SortFunc(migrations, func(a, b migration) int {
+		return a.version - b.version
+	})
+
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].version == migrations[i-1].version {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", migrations[i-1].name, migrations[i].name, migrations[i].version)
+		}
+	}
+
+	return migrations, nil
+}
+
+// appliedMigrations returns when each recorded migration ran, without
+// creating schema_migrations if it doesn't exist yet.
+func appliedMigrations(db *sql.DB) (map[int]time.Time, error) {
+	applied := make(map[int]time.Time)
+
+	var tables int
+
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'").Scan(&tables); err != nil {
+		return nil, err
+	}
+
+	if tables == 0 {
+		return applied, nil
+	}
+
+	rows, err := db.Query("SELECT Version, AppliedAt FROM schema_migrations")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// migrate applies every migration that hasn't been yet, oldest first. A
+// database migrated by a newer build is refused rather than guessed at.
+func migrate(db *sql.DB) error {
+	migrations, err := loadMigrations()
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(createSchemaMigrations); err != nil {
+		return err
+	}
+
+	applied, err := appliedMigrations(db)
+
+	if err != nil {
+		return err
+	}
+
+	latest := migrations[len(migrations)-1].version
+
+	for version := range applied {
+		if version > latest {
+			return fmt.Errorf("database is at schema version %d, but this build only knows up to %d", version, latest)
+		}
+	}
+
+	for _, m := range migrations {
+		if _, ok := applied[m.version]; ok {
+			continue
+		}
+
+		log.Info().Msgf("Applying migration %04d_%s", m.version, m.name)
+
+		if err := applyMigration(db, m); err != nil {
+			return fmt.Errorf("migration %04d_%s: %w", m.version, m.name, err)
+		}
+	}
+
+	return nil
+}
+
+func applyMigration(db *sql.DB, m migration) error {
+	tx, err := db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err := m.up(tx); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(
+		"INSERT INTO schema_migrations (Version, Name, AppliedAt) VALUES (?, ?, ?)",
+		m.version,
+		m.name,
+		time.Now(),
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetMigrationStatus lists every migration this build knows about, and any
+// the event store records that it doesn't, without changing the store.
+func GetMigrationStatus() ([]MigrationStatus, error) {
+	migrations, err := loadMigrations()
+
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]time.Time)
+
+	// Opening a missing store would create it.
+	if _, err := os.Stat(config.Get().EventStorePath); err == nil {
+		db, err := openEventStore()
+
+		if err != nil {
+			return nil, err
+		}
+
+		defer db.Close()
+
+		if applied, err = appliedMigrations(db); err != nil {
+			return nil, err
+		}
+	}
+
+	var statuses []MigrationStatus
+
+	for _, m := range migrations {
+		status := MigrationStatus{Version: m.version, Name: m.name}
+
+		if appliedAt, ok := applied[m.version]; ok {
+			status.AppliedAt = &appliedAt
+			delete(applied, m.version)
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	for version, appliedAt := range applied {
+		statuses = append(statuses, MigrationStatus{Version: version, Name: "(unknown)", AppliedAt: &appliedAt})
+	}
+
+	slices.SortFunc(statuses, func(a, b MigrationStatus) int {
+		return a.Version - b.Version
+	})
+
+	return statuses, nil
+}
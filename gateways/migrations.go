@@ -0,0 +1,483 @@
+package gateways
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// goMigrations are the migrations that need more than SQL. They are ordered
+// together with the files in migrations/, and a version, once released, must
+// never change.
+var goMigrations = []migration{
+	{version: 2, name: "add_venue_columns", up: addColumns("calendar_events", venueColumns)},
+	{version: 3, name: "add_ticket_columns", up: addColumns("calendar_events", ticketColumns)},
+	{version: 4, name: "add_image_columns", up: addColumns("calendar_events", imageColumns)},
+	{version: 5, name: "add_status_columns", up: addColumns("calendar_events", statusColumns)},
+	{version: 6, name: "add_identity_columns", up: addColumns("calendar_events", identityColumns)},
+	{version: 7, name: "identify_events", up: identifyEvents},
+	{version: 9, name: "add_duplicate_columns", up: addColumns("calendar_events", duplicateColumns)},
+	{version: 11, name: "move_tags", up: moveTags},
+	{version: 12, name: "scope_feed_sources", up: scopeFeedSources},
+}
+
+// venueColumns were the first columns added after the table was created.
+var venueColumns = []string{
+	"VenueName TEXT NOT NULL DEFAULT ''",
+	"VenueStreetAddress TEXT NOT NULL DEFAULT ''",
+	"VenueCity TEXT NOT NULL DEFAULT ''",
+	"VenueRegion TEXT NOT NULL DEFAULT ''",
+	"VenuePostalCode TEXT NOT NULL DEFAULT ''",
+	"VenueCountry TEXT NOT NULL DEFAULT ''",
+	"VenueLatitude REAL",
+	"VenueLongitude REAL",
+	"VenueOnline BOOLEAN NOT NULL DEFAULT FALSE",
+}
+
+// ticketColumns were added alongside price extraction, the same way.
+var ticketColumns = []string{
+	"TicketPrice REAL",
+	"TicketMaxPrice REAL",
+	"TicketCurrency TEXT NOT NULL DEFAULT ''",
+	"TicketFree BOOLEAN NOT NULL DEFAULT FALSE",
+	"TicketSoldOut BOOLEAN NOT NULL DEFAULT FALSE",
+	"TicketURL TEXT NOT NULL DEFAULT ''",
+}
+
+var imageColumns = []string{
+	"ImageURL TEXT NOT NULL DEFAULT ''",
+	"Thumbnail TEXT NOT NULL DEFAULT ''",
+}
+
+// identityColumns identify an event by where it came from rather than by
+// what it says. See identifyEvents.
+var identityColumns = []string{
+	"Source TEXT NOT NULL DEFAULT ''",
+	"SourceID TEXT NOT NULL DEFAULT ''",
+	"ContentHash TEXT NOT NULL DEFAULT ''",
+}
+
+// statusColumns record whether an event is still on and when its page was
+// last re-checked.
+var statusColumns = []string{
+	"Status TEXT NOT NULL DEFAULT 'scheduled'",
+	"StatusCheckedAt DATETIME",
+}
+
+// duplicateColumns point events listed on several sources at the copy that
+// is shown. An empty CanonicalID means the event is shown itself.
+var duplicateColumns = []string{
+	"CanonicalID TEXT NOT NULL DEFAULT ''",
+}
+
+// addColumns returns a migration adding every column definition whose column
+// doesn't exist yet. Databases created before migrations were recorded may
+// already have some of them.
+func addColumns(table string, definitions []string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		return ensureColumns(tx, table, definitions)
+	}
+}
+
+func ensureColumns(tx *sql.Tx, table string, definitions []string) error {
+	rows, err := tx.Query(fmt.Sprintf("SELECT name FROM pragma_table_info('%s')", table))
+
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]bool)
+
+	for rows.Next() {
+		var name string
+
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+
+		existing[strings.ToLower(name)] = true
+	}
+
+	rows.Close()
+
+	for _, definition := range definitions {
+		name := strings.Fields(definition)[0]
+
+		if existing[strings.ToLower(name)] {
+			continue
+		}
+
+		if _, err := tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", table, definition)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// identifyEvents gives events stored before IDs were derived from their
+// source an ID that is, collapsing the copies made each time such an event
+// was edited upstream into the most recently stored one. Their old ID was a
+// hash of their content, so it becomes their ContentHash.
+func identifyEvents(tx *sql.Tx) error {
+	rows, err := tx.Query("SELECT ID, OriginURL, StartTime, Metadata FROM calendar_events WHERE Source = '' ORDER BY rowid")
+
+	if err != nil {
+		return err
+	}
+
+	type legacyEvent struct {
+		id       string
+		source   string
+		sourceID string
+	}
+
+	groups := make(map[string][]legacyEvent)
+
+	for rows.Next() {
+		var id, originURL, rawMeta string
+		var start time.Time
+		var metadata map[string]string
+
+		if err := rows.Scan(&id, &originURL, &start, &rawMeta); err != nil {
+			rows.Close()
+			return err
+		}
+
+		if err := json.Unmarshal([]byte(rawMeta), &metadata); err != nil {
+			log.Warn().Err(err).Msgf("Unable to read the metadata of event %s", id)
+		}
+
+		source, sourceID := legacySource(originURL, start, metadata)
+
+		if sourceID != "" {
+			newID := legacyEventID(source, sourceID)
+			groups[newID] = append(groups[newID], legacyEvent{id: id, source: source, sourceID: sourceID})
+		}
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	removed := 0
+
+	for newID, group := range groups {
+		keep := group[len(group)-1]
+		stale := group[:len(group)-1]
+
+		var existing int
+
+		if err := tx.QueryRow("SELECT COUNT(*) FROM calendar_events WHERE ID = ?", newID).Scan(&existing); err != nil {
+			return err
+		}
+
+		// The event has already been stored again under its new ID.
+		if existing > 0 {
+			stale = group
+		}
+
+		for _, event := range stale {
+			if _, err := tx.Exec("DELETE FROM calendar_events WHERE ID = ?", event.id); err != nil {
+				return err
+			}
+		}
+
+		removed += len(stale)
+
+		if existing > 0 {
+			continue
+		}
+
+		if _, err := tx.Exec(
+			"UPDATE calendar_events SET ID = ?, Source = ?, SourceID = ?, ContentHash = ID WHERE ID = ?",
+			newID,
+			keep.source,
+			keep.sourceID,
+			keep.id,
+		); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec("UPDATE calendar_events SET ContentHash = ID WHERE ContentHash = ''"); err != nil {
+		return err
+	}
+
+	if len(groups) > 0 {
+		log.Info().Msgf("Identified %d stored events, removing %d duplicates", len(groups), removed)
+	}
+
+	return nil
+}
+
+// legacySource works out the source of an event stored before it was
+// recorded, the same way the strategies did when identify_events was
+// written.
+func legacySource(originURL string, start time.Time, metadata map[string]string) (string, string) {
+	if uid := metadata["ics-uid"]; uid != "" {
+		if recurrenceID, err := time.Parse(time.RFC3339, metadata["ics-recurrence-id"]); err == nil {
+			start = recurrenceID
+		}
+
+		return "ics", uid + "@" + start.UTC().Format(time.RFC3339)
+	}
+
+	if guid, ok := metadata["feed-guid"]; ok {
+		if guid = strings.TrimSpace(guid); guid != "" {
+			return "rss", guid
+		}
+
+		return "rss", legacyCanonicalURL(originURL)
+	}
+
+	return legacySourceFromURL(originURL)
+}
+
+// The migrations identify events with these copies of util's identity
+// functions, which must keep giving the IDs they gave when the migrations
+// were released whatever happens to the originals.
+
+var legacyMeetupPattern = regexp.MustCompile(`^(?:www\.)?meetup\.com/[^/]+/events/(\d+)`)
+var legacyEventbritePattern = regexp.MustCompile(`^(?:www\.)?eventbrite\.[a-z.]+/e/(?:[^/]*-)?(\d+)/?$`)
+var legacyLumaPattern = regexp.MustCompile(`^(?:www\.)?(?:lu\.ma|luma\.com)/([A-Za-z0-9_-]+)/?$`)
+
+// legacyCanonicalURL is util.CanonicalURL.
+func legacyCanonicalURL(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+
+	if err != nil || u.Host == "" {
+		return strings.TrimSpace(rawURL)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	query := u.Query()
+
+	for key := range query {
+		if strings.HasPrefix(key, "utm_") || slices.Contains([]string{"aff", "fbclid", "gclid", "ref", "mc_cid", "mc_eid"}, key) {
+			query.Del(key)
+		}
+	}
+
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+// legacySourceFromURL is util.SourceFromURL.
+func legacySourceFromURL(rawURL string) (string, string) {
+	canonical := legacyCanonicalURL(rawURL)
+	u, err := url.Parse(canonical)
+
+	if err != nil || u.Host == "" {
+		return "", canonical
+	}
+
+	for source, pattern := range map[string]*regexp.Regexp{
+		"meetup":     legacyMeetupPattern,
+		"eventbrite": legacyEventbritePattern,
+		"luma":       legacyLumaPattern,
+	} {
+		if m := pattern.FindStringSubmatch(u.Host + u.Path); m != nil {
+			return source, m[1]
+		}
+	}
+
+	return strings.TrimPrefix(u.Host, "www."), canonical
+}
+
+// legacyEventID is util.GetEventID.
+func legacyEventID(source, sourceID string) string {
+	hash := sha256.Sum256([]byte(source + "\x00" + sourceID))
+
+	return hex.EncodeToString(hash[:])
+}
+
+// moveTags copies the comma-separated Tags column into event_tags, then
+// drops it.
+func moveTags(tx *sql.Tx) error {
+	rows, err := tx.Query("SELECT ID, Tags FROM calendar_events WHERE Tags != ''")
+
+	if err != nil {
+		return err
+	}
+
+	tags := make(map[string][]string)
+
+	for rows.Next() {
+		var id string
+		var joined sql.NullString
+
+		if err := rows.Scan(&id, &joined); err != nil {
+			rows.Close()
+			return err
+		}
+
+		tags[id] = strings.Split(joined.String, ",")
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, eventTags := range tags {
+		if err := insertLegacyTags(tx, id, eventTags); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec("ALTER TABLE calendar_events DROP COLUMN Tags"); err != nil {
+		return err
+	}
+
+	log.Info().Msgf("Moved the tags of %d events into event_tags", len(tags))
+
+	return nil
+}
+
+// insertLegacyTags adds an event's tags to event_tags in order, the way
+// setTags did when move_tags was written.
+func insertLegacyTags(tx *sql.Tx, eventID string, tags []string) error {
+	var seen []string
+
+	for _, tag := range tags {
+		if tag = strings.TrimSpace(tag); tag == "" || slices.Contains(seen, tag) {
+			continue
+		}
+
+		if _, err := tx.Exec("INSERT INTO tags (Name) VALUES (?) ON CONFLICT (Name) DO NOTHING", tag); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(
+			"INSERT INTO event_tags (EventID, TagID, Position) SELECT ?, ID, ? FROM tags WHERE Name = ?",
+			eventID,
+			len(seen),
+			tag,
+		); err != nil {
+			return err
+		}
+
+		seen = append(seen, tag)
+	}
+
+	return nil
+}
+
+// scopeFeedSources moves events read from feeds to a source named after
+// their feed, as the strategies now do. Events stored without their feed
+// can't be moved and keep the unnamed "rss" or "ics" source.
+func scopeFeedSources(tx *sql.Tx) error {
+	rows, err := tx.Query("SELECT ID, Source, SourceID, Metadata FROM calendar_events WHERE Source IN ('rss', 'ics')")
+
+	if err != nil {
+		return err
+	}
+
+	type feedEvent struct {
+		id       string
+		source   string
+		sourceID string
+	}
+
+	var events []feedEvent
+	unscoped := 0
+
+	for rows.Next() {
+		var id, source, sourceID, rawMeta string
+		var metadata map[string]string
+
+		if err := rows.Scan(&id, &source, &sourceID, &rawMeta); err != nil {
+			rows.Close()
+			return err
+		}
+
+		if err := json.Unmarshal([]byte(rawMeta), &metadata); err != nil {
+			log.Warn().Err(err).Msgf("Unable to read the metadata of event %s", id)
+		}
+
+		feed := metadata["ics-feed"]
+
+		if source == "rss" {
+			feed = metadata["feed-url"]
+		}
+
+		if feed == "" {
+			unscoped++
+			continue
+		}
+
+		events = append(events, feedEvent{id: id, source: source + ":" + legacyCanonicalURL(feed), sourceID: sourceID})
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		if err := renameEvent(tx, event.id, legacyEventID(event.source, event.sourceID), event.source); err != nil {
+			return err
+		}
+	}
+
+	if unscoped > 0 {
+		log.Warn().Msgf("%d RSS or iCalendar events don't record their feed and keep an unscoped source", unscoped)
+	}
+
+	return nil
+}
+
+// renameEvent gives an event a new ID and source, along with its tags,
+// revisions and duplicates. If an event already has the new ID, the old one
+// is a stale copy of it and is removed instead.
+func renameEvent(tx *sql.Tx, oldID, newID, source string) error {
+	var existing int
+
+	if err := tx.QueryRow("SELECT COUNT(*) FROM calendar_events WHERE ID = ?", newID).Scan(&existing); err != nil {
+		return err
+	}
+
+	statements := []string{
+		"UPDATE calendar_events SET ID = ?2, Source = ?3 WHERE ID = ?1",
+		"UPDATE event_tags SET EventID = ?2 WHERE EventID = ?1",
+		"UPDATE event_revisions SET EventID = ?2 WHERE EventID = ?1",
+		"UPDATE calendar_events SET CanonicalID = ?2 WHERE CanonicalID = ?1",
+	}
+
+	if existing > 0 {
+		statements = []string{
+			"DELETE FROM calendar_events WHERE ID = ?1",
+			"DELETE FROM event_tags WHERE EventID = ?1",
+			"DELETE FROM event_revisions WHERE EventID = ?1",
+			"UPDATE calendar_events SET CanonicalID = '' WHERE CanonicalID = ?1",
+		}
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, oldID, newID, source); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,15 @@
+-- The table as it was first created. Columns added since then each have a
+-- migration of their own.
+CREATE TABLE IF NOT EXISTS calendar_events (
+	ID TEXT PRIMARY KEY,
+	Name TEXT,
+	StartTime DATETIME,
+	EndTime DATETIME,
+	Location TEXT,
+	Description TEXT,
+	OriginURL TEXT,
+	Tags TEXT,
+	Processed BOOLEAN,
+	Relevant BOOLEAN,
+	Metadata TEXT
+);
@@ -0,0 +1,12 @@
+-- Every change to an event seen on a crawl. Changes is a JSON object of
+-- field names to their old and new values.
+CREATE TABLE IF NOT EXISTS event_revisions (
+	ID INTEGER PRIMARY KEY AUTOINCREMENT,
+	EventID TEXT NOT NULL,
+	Kind TEXT NOT NULL,
+	RecordedAt DATETIME NOT NULL,
+	ContentHash TEXT NOT NULL,
+	Changes TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS event_revisions_event ON event_revisions (EventID, RecordedAt);
@@ -0,0 +1,15 @@
+-- Tags are shared between events. Position keeps an event's tags in the
+-- order they were given.
+CREATE TABLE IF NOT EXISTS tags (
+	ID INTEGER PRIMARY KEY AUTOINCREMENT,
+	Name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS event_tags (
+	EventID TEXT NOT NULL,
+	TagID INTEGER NOT NULL REFERENCES tags (ID),
+	Position INTEGER NOT NULL,
+	PRIMARY KEY (EventID, TagID)
+);
+
+CREATE INDEX IF NOT EXISTS event_tags_tag ON event_tags (TagID, EventID);
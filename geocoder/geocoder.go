@@ -0,0 +1,262 @@
+package geocoder
+
+import (
+	"bufio"
+	"celeve/models"
+	"celeve/util"
+	"embed"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const earthRadiusKm = 6371.0
+
+//go:embed data
+var data embed.FS
+
+// regionCodes maps the region names that show up in addresses to the codes
+// used by the gazetteer.
+var regionCodes = map[string]string{
+	"alabama": "al", "alaska": "ak", "arizona": "az", "arkansas": "ar",
+	"california": "ca", "colorado": "co", "connecticut": "ct", "delaware": "de",
+	"district of columbia": "dc", "florida": "fl", "georgia": "ga", "hawaii": "hi",
+	"idaho": "id", "illinois": "il", "indiana": "in", "iowa": "ia",
+	"kansas": "ks", "kentucky": "ky", "louisiana": "la", "maine": "me",
+	"maryland": "md", "massachusetts": "ma", "michigan": "mi", "minnesota": "mn",
+	"mississippi": "ms", "missouri": "mo", "montana": "mt", "nebraska": "ne",
+	"nevada": "nv", "new hampshire": "nh", "new jersey": "nj", "new mexico": "nm",
+	"new york": "ny", "north carolina": "nc", "north dakota": "nd", "ohio": "oh",
+	"oklahoma": "ok", "oregon": "or", "pennsylvania": "pa", "rhode island": "ri",
+	"south carolina": "sc", "south dakota": "sd", "tennessee": "tn", "texas": "tx",
+	"utah": "ut", "vermont": "vt", "virginia": "va", "washington": "wa",
+	"west virginia": "wv", "wisconsin": "wi", "wyoming": "wy",
+	"alberta": "ab", "british columbia": "bc", "ontario": "on", "quebec": "qc",
+}
+
+type point struct {
+	latitude  float64
+	longitude float64
+}
+
+// Geocoder resolves venues to coordinates from a local gazetteer, so no
+// network access is needed. It knows postal code centroids and city or
+// neighbourhood centroids, so results are only as precise as those.
+type Geocoder struct {
+	postal map[string]point
+	places map[string]point
+	names  map[string][]point
+}
+
+// New loads the built-in gazetteer and, if path is set, a GeoNames postal
+// code (allCountries.txt from the zip export) or cities (cities15000.txt)
+// dump on top of it.
+func New(path string) (*Geocoder, error) {
+	g := &Geocoder{
+		postal: make(map[string]point),
+		places: make(map[string]point),
+		names:  make(map[string][]point),
+	}
+
+	builtin, err := data.Open("data/places.tsv")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer builtin.Close()
+
+	if err := g.load(builtin); err != nil {
+		return nil, fmt.Errorf("built-in gazetteer: %w", err)
+	}
+
+	if path == "" {
+		return g, nil
+	}
+
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	if err := g.load(file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return g, nil
+}
+
+// load reads tab separated rows, telling the formats apart by column count:
+// 5 for the built-in file, 12 for GeoNames postal codes and 19 for GeoNames
+// cities.
+func (g *Geocoder) load(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	line := 0
+
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		var err error
+
+		switch fields := strings.Split(text, "\t"); len(fields) {
+		case 5:
+			err = g.add(fields[0], fields[1], fields[2], "", fields[3], fields[4])
+		case 12:
+			err = g.add(fields[0], fields[4], fields[2], fields[1], fields[9], fields[10])
+		case 19:
+			if err = g.add(fields[8], fields[10], fields[1], "", fields[4], fields[5]); err == nil && fields[2] != fields[1] {
+				err = g.add(fields[8], fields[10], fields[2], "", fields[4], fields[5])
+			}
+		default:
+			err = fmt.Errorf("unexpected %d columns", len(fields))
+		}
+
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+
+	return scanner.Err()
+}
+
+func (g *Geocoder) add(country, region, place, postalCode, latitude, longitude string) error {
+	lat, err := strconv.ParseFloat(latitude, 64)
+
+	if err != nil {
+		return err
+	}
+
+	lon, err := strconv.ParseFloat(longitude, 64)
+
+	if err != nil {
+		return err
+	}
+
+	p := point{latitude: lat, longitude: lon}
+	country = normalize(country)
+	region = normalize(region)
+	name := normalize(place)
+
+	if postalCode != "" {
+		g.postal[country+"|"+normalize(postalCode)] = p
+	}
+
+	if name == "" {
+		return nil
+	}
+
+	// Postal files repeat a place once per code; the first one is kept.
+	if _, ok := g.places[country+"|"+region+"|"+name]; !ok {
+		g.places[country+"|"+region+"|"+name] = p
+		g.names[name] = append(g.names[name], p)
+	}
+
+	return nil
+}
+
+// Geocode returns the coordinates of venue, trying its postal code first and
+// then its city (or neighbourhood) with whatever region and country it has.
+func (g *Geocoder) Geocode(venue models.Venue) (float64, float64, bool) {
+	if venue.Online {
+		return 0, 0, false
+	}
+
+	country := normalizeCountry(venue.Country)
+	region := normalizeRegion(venue.Region)
+	city := normalize(venue.City)
+
+	if venue.PostalCode != "" {
+		postalCode := normalize(venue.PostalCode)
+
+		// ZIP+4 codes are looked up by their first five digits.
+		if len(postalCode) == 10 && postalCode[5] == '-' {
+			postalCode = postalCode[:5]
+		}
+
+		for _, c := range candidates(country) {
+			if p, ok := g.postal[c+"|"+postalCode]; ok {
+				return p.latitude, p.longitude, true
+			}
+		}
+	}
+
+	if city == "" {
+		return 0, 0, false
+	}
+
+	for _, c := range candidates(country) {
+		if p, ok := g.places[c+"|"+region+"|"+city]; ok {
+			return p.latitude, p.longitude, true
+		}
+	}
+
+	// Without a region to go on, only trust names that are unambiguous.
+	if matches := g.names[city]; len(matches) == 1 {
+		return matches[0].latitude, matches[0].longitude, true
+	}
+
+	return 0, 0, false
+}
+
+// DistanceKm returns the great-circle distance between two points.
+func DistanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1 := lat1 * math.Pi / 180
+	phi2 := lat2 * math.Pi / 180
+	dPhi := (lat2 - lat1) * math.Pi / 180
+	dLambda := (lon2 - lon1) * math.Pi / 180
+
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+
+	return 2 * earthRadiusKm * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// candidates lists the countries to try: the venue's own, or the US when it
+// has none, since most sources leave the country off domestic addresses.
+func candidates(country string) []string {
+	if country != "" {
+		return []string{country}
+	}
+
+	return []string{"us", ""}
+}
+
+func normalize(value string) string {
+	value = strings.ToLower(strings.ReplaceAll(value, ".", ""))
+
+	return strings.Join(strings.Fields(value), " ")
+}
+
+func normalizeRegion(region string) string {
+	region = normalize(region)
+
+	if code, ok := regionCodes[region]; ok {
+		return code
+	}
+
+	return region
+}
+
+func normalizeCountry(country string) string {
+	if country = strings.TrimSpace(country); country == "" {
+		return ""
+	}
+
+	if code, err := util.GetISO3166Alpha2(country); err == nil {
+		return code
+	}
+
+	return normalize(country)
+}
@@ -0,0 +1,68 @@
+package geocoder
+
+import (
+	"celeve/models"
+	"math"
+	"testing"
+)
+
+func TestGeocode(t *testing.T) {
+	g, err := New("testdata/postal.txt")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		venue     models.Venue
+		latitude  float64
+		longitude float64
+		ok        bool
+	}{
+		{"postal code", models.Venue{PostalCode: "10001"}, 40.7484, -73.9967, true},
+		{"ZIP+4", models.Venue{PostalCode: "10001-2062", City: "New York"}, 40.7484, -73.9967, true},
+		{"postal code beats city", models.Venue{PostalCode: "11201", City: "New York", Region: "NY"}, 40.6944, -73.9906, true},
+		{"city and region code", models.Venue{City: "Portland", Region: "OR"}, 45.5152, -122.6784, true},
+		{"city and region name", models.Venue{City: "Portland", Region: "Maine", Country: "United States"}, 43.6615, -70.2553, true},
+		{"ambiguous city", models.Venue{City: "Portland"}, 0, 0, false},
+		{"unambiguous city", models.Venue{City: "brooklyn"}, 40.6782, -73.9442, true},
+		{"unknown postal code falls back to city", models.Venue{PostalCode: "99999", City: "Manhattan", Region: "NY"}, 40.7831, -73.9712, true},
+		{"unknown city", models.Venue{City: "Atlantis"}, 0, 0, false},
+		{"online", models.Venue{Online: true, City: "New York", Region: "NY"}, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			latitude, longitude, ok := g.Geocode(tt.venue)
+
+			if ok != tt.ok || latitude != tt.latitude || longitude != tt.longitude {
+				t.Errorf("Geocode(%+v) = %v, %v, %v; want %v, %v, %v", tt.venue, latitude, longitude, ok, tt.latitude, tt.longitude, tt.ok)
+			}
+		})
+	}
+}
+
+func TestDistanceKm(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lat1, lon1, lat2, lon2 float64
+		want                   float64
+	}{
+		{"same point", 40.7128, -74.0060, 40.7128, -74.0060, 0},
+		{"New York to Los Angeles", 40.7128, -74.0060, 34.0522, -118.2437, 3936},
+		{"across Manhattan", 40.7484, -73.9967, 40.7831, -73.9712, 4.4},
+		{"antipodes", 0, 0, 0, 180, math.Pi * earthRadiusKm},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DistanceKm(tt.lat1, tt.lon1, tt.lat2, tt.lon2)
+
+			// Within 1% of the expected distance, or 10 m.
+			if math.Abs(got-tt.want) > math.Max(tt.want*0.01, 0.01) {
+				t.Errorf("DistanceKm = %.3f, want %.3f", got, tt.want)
+			}
+		})
+	}
+}
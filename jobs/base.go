@@ -1,6 +1,45 @@
 package jobs
 
+import (
+	"context"
+	"sync"
+)
+
 type Job interface {
-	Start()
+	Start(ctx context.Context)
 	Stop() error
 }
+
+// lifecycle gives a job a Stop that cancels the context its Start loop and
+// crawls run under. Embed it and call begin at the top of Start.
+type lifecycle struct {
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	stopped bool
+}
+
+func (l *lifecycle) begin(ctx context.Context) context.Context {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ctx, l.cancel = context.WithCancel(ctx)
+
+	if l.stopped {
+		l.cancel()
+	}
+
+	return ctx
+}
+
+func (l *lifecycle) Stop() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.stopped = true
+
+	if l.cancel != nil {
+		l.cancel()
+	}
+
+	return nil
+}
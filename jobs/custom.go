@@ -0,0 +1,199 @@
+package jobs
+
+import (
+	"celeve/browser"
+	"celeve/config"
+	"celeve/extractors"
+	"celeve/fetcher"
+	"celeve/models"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/chromedp/chromedp"
+	"github.com/rs/zerolog/log"
+)
+
+// customStrategy crawls a site described by a config.CustomStrategyConfig, so
+// new calendars can be added without writing a strategy for each.
+type customStrategy struct {
+	lifecycle
+	schedule *schedule
+	config   config.CustomStrategyConfig
+	channel  chan models.CalendarEvent
+	pattern  *regexp.Regexp
+	pool     *browser.Pool
+	fetcher  *fetcher.Fetcher
+}
+
+func NewCustomStrategy(params config.CustomStrategyConfig, calendarChan chan models.CalendarEvent, pool *browser.Pool, fetcher *fetcher.Fetcher) (Job, error) {
+	sched, err := newSchedule(params.Schedule)
+
+	if err != nil {
+		return nil, err
+	}
+
+	pattern, err := regexp.Compile(params.LinkPattern)
+
+	if err != nil {
+		return nil, err
+	}
+
+	params.Tags = append(params.Tags, params.Name)
+
+	return &customStrategy{
+		schedule: sched,
+		config:   params,
+		channel:  calendarChan,
+		pattern:  pattern,
+		pool:     pool,
+		fetcher:  fetcher,
+	}, nil
+}
+
+func (s *customStrategy) Start(ctx context.Context) {
+	runSchedule(s.begin(ctx), fmt.Sprintf("Custom strategy %s", s.config.Name), s.schedule, s.perform)
+}
+
+func (s *customStrategy) perform(ctx context.Context) error {
+	seen := make(map[string]bool)
+	pages := max(s.config.Pages, 1)
+
+	for page := 1; page <= pages; page++ {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		listingURL := s.listingURL(page)
+		log.Info().Msgf("Retrieving %s listing %s", s.config.Name, listingURL)
+
+		urls, err := s.getEventUrls(ctx, listingURL)
+
+		if err != nil {
+			log.Error().Err(err).Msgf("Failed to get %s listing page %d", s.config.Name, page)
+			continue
+		}
+
+		for _, u := range urls {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			if seen[u] {
+				continue
+			}
+
+			seen[u] = true
+			log.Info().Msgf("Extracting url: %s", u)
+			s.extractEvent(ctx, u)
+		}
+
+		// Without a {page} placeholder every page is the same listing.
+		if !strings.Contains(s.config.ListingURL, "{page}") {
+			break
+		}
+	}
+
+	return nil
+}
+
+func (s *customStrategy) listingURL(page int) string {
+	return strings.ReplaceAll(s.config.ListingURL, "{page}", strconv.Itoa(page))
+}
+
+// getEventUrls returns the event links on a listing page, fetched over plain
+// HTTP when that finds any and rendered in a browser otherwise.
+func (s *customStrategy) getEventUrls(ctx context.Context, listingURL string) ([]string, error) {
+	if !s.config.Render {
+		doc, err := s.fetcher.GetDocument(ctx, listingURL)
+
+		if err == nil {
+			if urls := s.matchLinks(doc, listingURL); len(urls) > 0 {
+				return urls, nil
+			}
+
+			err = errors.New("no event links in server-rendered listing")
+		}
+
+		log.Info().Err(err).Msgf("Falling back to browser for %s listing", s.config.Name)
+	}
+
+	htmlContent, err := s.renderListing(ctx, listingURL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlContent))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.matchLinks(doc, listingURL), nil
+}
+
+func (s *customStrategy) renderListing(ctx context.Context, listingURL string) (string, error) {
+	var htmlContent string
+
+	ctx, cancel, err := s.pool.Tab(ctx)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer cancel()
+
+	err = chromedp.Run(ctx,
+		chromedp.Navigate(listingURL),
+		browser.Scroll(s.config.Scrolls),
+		chromedp.OuterHTML("html", &htmlContent),
+	)
+
+	return htmlContent, err
+}
+
+// matchLinks resolves every link in doc against the listing URL and keeps the
+// ones matching the configured link pattern.
+func (s *customStrategy) matchLinks(doc *goquery.Document, listingURL string) []string {
+	var result []string
+
+	base, err := url.Parse(listingURL)
+
+	if err != nil {
+		return nil
+	}
+
+	doc.Find("a[href]").Each(func(_ int, sel *goquery.Selection) {
+		href, err := base.Parse(sel.AttrOr("href", ""))
+
+		if err != nil {
+			return
+		}
+
+		href.Fragment = ""
+
+		if u := href.String(); s.pattern.MatchString(u) {
+			result = append(result, u)
+		}
+	})
+
+	return result
+}
+
+func (s *customStrategy) extractEvent(ctx context.Context, url string) {
+	extractor := extractors.NewCustomExtractor(url, s.config, s.pool, s.fetcher)
+	event, err := extractor.GetEvent(ctx)
+
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed to get %s event", s.config.Name)
+	} else {
+		log.Info().Msgf("Pushing event %s", event.ID)
+		s.channel <- *event
+	}
+}
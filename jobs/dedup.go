@@ -0,0 +1,260 @@
+package jobs
+
+import (
+	"celeve/geocoder"
+	"celeve/models"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// dedupStartWindow is how far apart two listings of one event may say it
+	// starts, since some sources round to the hour or open doors early.
+	dedupStartWindow = 30 * time.Minute
+	// dedupSimilarity is the least similarity of two titles, or venue names,
+	// for them to count as the same.
+	dedupSimilarity = 0.8
+	// dedupVenueRadiusKm is how far apart two geocoded venues may be.
+	dedupVenueRadiusKm = 0.5
+	// dedupLookback keeps events that have just started in the running.
+	dedupLookback = 12 * time.Hour
+)
+
+// dedupe finds upcoming events listed on more than one source and points
+// every listing but the most complete at it. Clusters are worked out afresh
+// each run, so an event that is edited until it no longer matches is shown
+// on its own again.
+func (s *processorJob) dedupe() error {
+	events, err := s.sqlite.GetEventsForDedup(time.Now().Add(-dedupLookback))
+
+	if err != nil {
+		return err
+	}
+
+	changes := make(map[string]string)
+	clusters := 0
+
+	for _, cluster := range clusterDuplicates(events) {
+		canonicalID := ""
+
+		if len(cluster) > 1 {
+			canonicalID = canonicalEvent(cluster).ID
+			clusters++
+		}
+
+		for _, event := range cluster {
+			want := canonicalID
+
+			if want == event.ID {
+				want = ""
+			}
+
+			if event.CanonicalID != want {
+				changes[event.ID] = want
+			}
+		}
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	log.Info().Msgf("Found %d events listed on several sources, updating %d listings", clusters, len(changes))
+
+	return s.sqlite.SetCanonicalIDs(changes)
+}
+
+// clusterDuplicates groups events, which must be in order of start time,
+// with any other listing of the same event. A cluster never holds two
+// listings from one source or at different venues, so a listing that leaves
+// its venue out can't join events that only match it.
+func clusterDuplicates(events []*models.CalendarEvent) [][]*models.CalendarEvent {
+	parents := make([]int, len(events))
+	members := make([][]int, len(events))
+	titles := make([]map[string]int, len(events))
+
+	for i, event := range events {
+		parents[i] = i
+		members[i] = []int{i}
+		titles[i] = bigrams(normalizeTitle(event.Name))
+	}
+
+	var find func(int) int
+
+	find = func(i int) int {
+		if parents[i] != i {
+			parents[i] = find(parents[i])
+		}
+
+		return parents[i]
+	}
+
+	for i, a := range events {
+		for j := i + 1; j < len(events); j++ {
+			b := events[j]
+
+			if b.StartTime.Sub(a.StartTime) > dedupStartWindow {
+				break
+			}
+
+			// Copies on one source are told apart by the source itself.
+			if a.Source == b.Source {
+				continue
+			}
+
+			if dice(titles[i], titles[j]) < dedupSimilarity || !sameVenue(a.Venue, b.Venue) {
+				continue
+			}
+
+			rootA, rootB := find(i), find(j)
+
+			if rootA != rootB && canMerge(events, members[rootA], members[rootB]) {
+				parents[rootB] = rootA
+				members[rootA] = append(members[rootA], members[rootB]...)
+				members[rootB] = nil
+			}
+		}
+	}
+
+	groups := make(map[int][]*models.CalendarEvent)
+	var roots []int
+
+	for i, event := range events {
+		root := find(i)
+
+		if _, ok := groups[root]; !ok {
+			roots = append(roots, root)
+		}
+
+		groups[root] = append(groups[root], event)
+	}
+
+	clusters := make([][]*models.CalendarEvent, len(roots))
+
+	for i, root := range roots {
+		clusters[i] = groups[root]
+	}
+
+	return clusters
+}
+
+// canMerge reports whether two clusters, given as indexes into events, could
+// be listings of one event.
+func canMerge(events []*models.CalendarEvent, a, b []int) bool {
+	for _, i := range a {
+		for _, j := range b {
+			if events[i].Source == events[j].Source || !sameVenue(events[i].Venue, events[j].Venue) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// canonicalEvent picks the listing that says the most about the event, so
+// the one shown has a venue, price and picture whenever any listing does.
+func canonicalEvent(cluster []*models.CalendarEvent) *models.CalendarEvent {
+	best := cluster[0]
+
+	for _, event := range cluster[1:] {
+		if score, bestScore := completeness(event), completeness(best); score > bestScore || (score == bestScore && event.ID < best.ID) {
+			best = event
+		}
+	}
+
+	return best
+}
+
+func completeness(event *models.CalendarEvent) int {
+	score := 0
+
+	for _, known := range []bool{
+		event.Venue.Name != "",
+		event.Venue.StreetAddress != "",
+		event.Venue.Latitude != nil,
+		!event.Ticketing.IsZero(),
+		event.ImageURL != "",
+		event.Description != "",
+		!event.EndTime.IsZero(),
+	} {
+		if known {
+			score++
+		}
+	}
+
+	return score
+}
+
+// sameVenue reports whether two venues could be the same place. Listings
+// that leave the venue out match any venue.
+func sameVenue(a, b models.Venue) bool {
+	if a.IsZero() || b.IsZero() {
+		return true
+	}
+
+	if a.Online || b.Online {
+		return a.Online == b.Online
+	}
+
+	if a.Latitude != nil && b.Latitude != nil {
+		return geocoder.DistanceKm(*a.Latitude, *a.Longitude, *b.Latitude, *b.Longitude) <= dedupVenueRadiusKm
+	}
+
+	if a.Name != "" && b.Name != "" {
+		return dice(bigrams(normalizeTitle(a.Name)), bigrams(normalizeTitle(b.Name))) >= dedupSimilarity
+	}
+
+	if a.City != "" && b.City != "" {
+		return strings.EqualFold(a.City, b.City)
+	}
+
+	return true
+}
+
+// normalizeTitle lowercases a title and reduces everything but letters and
+// digits to single spaces, so "AI Meetup: Agents!" and "ai meetup - agents"
+// are the same.
+func normalizeTitle(title string) string {
+	fields := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	return strings.Join(fields, " ")
+}
+
+// bigrams counts the pairs of adjacent characters in s.
+func bigrams(s string) map[string]int {
+	runes := []rune(s)
+	counts := make(map[string]int)
+
+	for i := 0; i+1 < len(runes); i++ {
+		counts[string(runes[i:i+2])]++
+	}
+
+	return counts
+}
+
+// dice is the Sørensen–Dice similarity of two bigram counts, from 0 for
+// nothing in common to 1 for the same string.
+func dice(a, b map[string]int) float64 {
+	total, shared := 0, 0
+
+	for bigram, n := range a {
+		total += n
+		shared += min(n, b[bigram])
+	}
+
+	for _, n := range b {
+		total += n
+	}
+
+	if total == 0 {
+		return 0
+	}
+
+	return 2 * float64(shared) / float64(total)
+}
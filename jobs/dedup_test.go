@@ -0,0 +1,75 @@
+package jobs
+
+import (
+	"celeve/models"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestClusterDuplicates(t *testing.T) {
+	start := time.Date(2024, 6, 10, 18, 0, 0, 0, time.UTC)
+	event := func(id, source, venue string, offset time.Duration) *models.CalendarEvent {
+		return &models.CalendarEvent{
+			ID:        id,
+			Source:    source,
+			Name:      "Python Meetup",
+			StartTime: start.Add(offset),
+			Venue:     models.Venue{Name: venue},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		events []*models.CalendarEvent
+		want   [][]string
+	}{
+		{
+			name: "listings on several sources",
+			events: []*models.CalendarEvent{
+				event("a", "meetup", "The Hall", 0),
+				event("b", "eventbrite", "The Hall", 0),
+				event("c", "luma", "", 15*time.Minute),
+			},
+			want: [][]string{{"a", "b", "c"}},
+		},
+		{
+			name: "venue-less listing doesn't join different venues",
+			events: []*models.CalendarEvent{
+				event("a", "meetup", "The Hall", 0),
+				event("b", "luma", "", 0),
+				event("c", "eventbrite", "Riverside Library", 0),
+			},
+			want: [][]string{{"a", "b"}, {"c"}},
+		},
+		{
+			name: "venue-less listing doesn't join one source's events",
+			events: []*models.CalendarEvent{
+				event("a", "meetup", "The Hall", 0),
+				event("b", "luma", "", 0),
+				event("c", "meetup", "The Hall", 10*time.Minute),
+			},
+			want: [][]string{{"a", "b"}, {"c"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [][]string
+
+			for _, cluster := range clusterDuplicates(tt.events) {
+				var ids []string
+
+				for _, event := range cluster {
+					ids = append(ids, event.ID)
+				}
+
+				got = append(got, ids)
+			}
+
+			if !slices.EqualFunc(got, tt.want, slices.Equal[[]string]) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
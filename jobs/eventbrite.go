@@ -1,12 +1,15 @@
 package jobs
 
 import (
+	"celeve/browser"
 	"celeve/config"
 	"celeve/extractors"
+	"celeve/fetcher"
 	"celeve/models"
 	"celeve/util"
 	"celeve/util/fsm"
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
@@ -17,6 +20,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/PuerkitoBio/goquery"
 	"github.com/chromedp/chromedp"
 	"github.com/rs/zerolog/log"
 )
@@ -24,6 +28,7 @@ import (
 var processUrlBatchSize = 5
 var extractEventBatchSize = 25
 var ebUrlRegex = regexp.MustCompile(`https?:\/\/(www\.)?eventbrite\.[^\/]+\/e\/.*`)
+var digitsRegex = regexp.MustCompile(`\d+`)
 
 const eventbriteUrlBase = "https://www.eventbrite.com/d/%s/%s/?page=%d"
 const getEventbritePages = `(() => {
@@ -38,51 +43,39 @@ const getEventbritePages = `(() => {
 })()`
 
 type eventbriteStrategy struct {
-	config    config.EventbriteStrategyConfig
-	userAgent string
-	channel   chan models.CalendarEvent
-	tags      []string
-	opts      []chromedp.ExecAllocatorOption
+	lifecycle
+	schedule *schedule
+	config   config.EventbriteStrategyConfig
+	channel  chan models.CalendarEvent
+	tags     []string
+	pool     *browser.Pool
+	fetcher  *fetcher.Fetcher
 }
 
-func NewEventbriteStrategy(params config.EventbriteStrategyConfig, calendarChan chan models.CalendarEvent, opts []chromedp.ExecAllocatorOption) (Job, error) {
-	return &eventbriteStrategy{
-		config:    params,
-		userAgent: config.Get().UserAgent,
-		channel:   calendarChan,
-		tags:      append(params.Tags, "eventbrite"),
-		opts:      opts,
-	}, nil
-}
-
-func (s *eventbriteStrategy) Start() {
-	ticker := time.NewTicker(config.Get().JobInterval)
-	defer ticker.Stop()
+func NewEventbriteStrategy(params config.EventbriteStrategyConfig, calendarChan chan models.CalendarEvent, pool *browser.Pool, fetcher *fetcher.Fetcher) (Job, error) {
+	sched, err := newSchedule(params.Schedule)
 
-	if err := s.perform(); err != nil {
-		log.Error().Err(err).Msg("Failed eventbrite strategy perform")
+	if err != nil {
+		return nil, err
 	}
 
-	for range ticker.C {
-		log.Info().Msg("Eventbrite strategy tick")
+	return &eventbriteStrategy{
+		schedule: sched,
+		config:   params,
+		channel:  calendarChan,
+		tags:     append(params.Tags, "eventbrite"),
+		pool:     pool,
+		fetcher:  fetcher,
+	}, nil
+}
 
-		if err := s.perform(); err != nil {
-			log.Error().Err(err).Msg("Failed eventbrite strategy perform")
-		}
-	}
+func (s *eventbriteStrategy) Start(ctx context.Context) {
+	runSchedule(s.begin(ctx), "Eventbrite strategy", s.schedule, s.perform)
 }
 
-func (s *eventbriteStrategy) perform() error {
+func (s *eventbriteStrategy) perform(ctx context.Context) error {
 	log.Info().Msg("Retrieving eventbrite listing")
 
-	opts := append(
-		chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.UserAgent(s.userAgent),
-	)
-	ctx, cancel := chromedp.NewExecAllocator(context.Background(), opts...)
-
-	defer cancel()
-
 	body, pages, err := s.getEventbriteBody(ctx, 1)
 
 	if err != nil && body == nil {
@@ -103,7 +96,7 @@ func (s *eventbriteStrategy) perform() error {
 	}()
 
 	go func() {
-		for i := 2; i <= pages; i++ {
+		for i := 2; i <= pages && ctx.Err() == nil; i++ {
 			sem <- true
 			wg.Add(1)
 			go s.processUrl(ctx, i, &wg, c, sem)
@@ -116,16 +109,16 @@ func (s *eventbriteStrategy) perform() error {
 		urls = append(urls, item)
 
 		if len(urls) == extractEventBatchSize {
-			s.extractEventbriteEvents(urls)
+			s.extractEventbriteEvents(ctx, urls)
 			urls = nil
 		}
 	}
 
 	if len(urls) > 0 {
-		s.extractEventbriteEvents(urls)
+		s.extractEventbriteEvents(ctx, urls)
 	}
 
-	return nil
+	return ctx.Err()
 }
 
 func (s *eventbriteStrategy) processUrl(ctx context.Context, i int, wg *sync.WaitGroup, c chan string, sem chan bool) {
@@ -160,15 +153,11 @@ func (s *eventbriteStrategy) processUrl(ctx context.Context, i int, wg *sync.Wai
 	}
 }
 
-func (s *eventbriteStrategy) Stop() error {
-	return nil
-}
-
 func (s *eventbriteStrategy) assembleEventbriteURL(page int) string {
 	return fmt.Sprintf(eventbriteUrlBase, s.config.Region, s.config.Query, page)
 }
 
-func (s *eventbriteStrategy) extractEventbriteEvents(urls []string) {
+func (s *eventbriteStrategy) extractEventbriteEvents(ctx context.Context, urls []string) {
 	defer func() {
 		if r := recover(); r != nil {
 			log.Error().Any("panic", r).Msg("Failed to extract eventbrite events")
@@ -176,8 +165,12 @@ func (s *eventbriteStrategy) extractEventbriteEvents(urls []string) {
 	}()
 
 	for _, url := range urls {
-		extractor := extractors.NewEventbriteExtractor(url, s.config.PrettyLocation, s.config.Tags, s.opts)
-		event, err := extractor.GetEvent()
+		if ctx.Err() != nil {
+			return
+		}
+
+		extractor := extractors.NewEventbriteExtractor(url, s.config.PrettyLocation, s.config.Tags, s.pool, s.fetcher)
+		event, err := extractor.GetEvent(ctx)
 
 		if err != nil {
 			log.Error().Err(err).Msg("Failed to get event")
@@ -224,16 +217,65 @@ func (s *eventbriteStrategy) extractEventbriteUrls(body *os.File) ([]string, err
 	return slices.Compact(result), nil
 }
 
+// getEventbriteBody saves a listing page to a temp file along with the total
+// number of pages. The page is fetched over plain HTTP when it is
+// server-rendered with event links, and rendered in a browser otherwise.
 func (s *eventbriteStrategy) getEventbriteBody(ctx context.Context, page int) (*os.File, int, error) {
+	body, pages, err := s.fetchEventbriteBody(ctx, page)
+
+	if err == nil {
+		return body, pages, nil
+	}
+
+	log.Info().Err(err).Msgf("Falling back to browser for eventbrite listing page %d", page)
+
+	return s.renderEventbriteBody(ctx, page)
+}
+
+func (s *eventbriteStrategy) fetchEventbriteBody(ctx context.Context, page int) (*os.File, int, error) {
+	htmlContent, err := s.fetcher.GetHTML(ctx, s.assembleEventbriteURL(page))
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if !ebUrlRegex.MatchString(htmlContent) {
+		return nil, 0, errors.New("no event links in server-rendered listing")
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlContent))
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	pagination := doc.Find(`[data-testid="pagination-parent"]`).First().Clone()
+	pagination.Find("span").First().Remove()
+	pages, err := strconv.Atoi(digitsRegex.FindString(pagination.Text()))
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to convert pages string")
+	}
+
+	body, err := util.SaveHtmlBody("eventbritelisting", htmlContent)
+
+	return body, pages, err
+}
+
+func (s *eventbriteStrategy) renderEventbriteBody(ctx context.Context, page int) (*os.File, int, error) {
 	var htmlContent string
 	url := s.assembleEventbriteURL(page)
-	ctx, cancel := chromedp.NewContext(ctx)
+	ctx, cancel, err := s.pool.Tab(ctx)
+
+	if err != nil {
+		return nil, 0, err
+	}
 
 	defer cancel()
 
 	var pagesStr string
 
-	err := chromedp.Run(ctx,
+	err = chromedp.Run(ctx,
 		chromedp.Navigate(url),
 		chromedp.WaitReady("body"),
 		chromedp.Evaluate(`window.scrollTo(0, document.body.scrollHeight);`, nil),
@@ -0,0 +1,187 @@
+package jobs
+
+import (
+	"celeve/config"
+	"celeve/extractors"
+	"celeve/fetcher"
+	"celeve/models"
+	"celeve/util"
+	"celeve/util/ical"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const defaultICSHorizon = 90 * 24 * time.Hour
+
+// icsStrategy reads iCalendar feeds, which many venues, universities and
+// community groups publish, and needs no browser at all.
+type icsStrategy struct {
+	lifecycle
+	schedule *schedule
+	config   config.ICSStrategyConfig
+	channel  chan models.CalendarEvent
+	tags     []string
+	tz       *time.Location
+	horizon  time.Duration
+	fetcher  *fetcher.Fetcher
+}
+
+func NewICSStrategy(params config.ICSStrategyConfig, calendarChan chan models.CalendarEvent, fetcher *fetcher.Fetcher) (Job, error) {
+	sched, err := newSchedule(params.Schedule)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tz, err := time.LoadLocation(params.Timezone)
+
+	if err != nil {
+		return nil, err
+	}
+
+	horizon := params.Horizon
+
+	if horizon == 0 {
+		horizon = defaultICSHorizon
+	}
+
+	return &icsStrategy{
+		schedule: sched,
+		config:   params,
+		channel:  calendarChan,
+		tags:     append(params.Tags, "ics"),
+		tz:       tz,
+		horizon:  horizon,
+		fetcher:  fetcher,
+	}, nil
+}
+
+func (s *icsStrategy) Start(ctx context.Context) {
+	runSchedule(s.begin(ctx), fmt.Sprintf("ICS strategy %s", s.config.Name), s.schedule, s.perform)
+}
+
+func (s *icsStrategy) perform(ctx context.Context) error {
+	for _, feed := range s.config.URLs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		log.Info().Msgf("Retrieving ics feed %s", feed)
+
+		if err := s.readFeed(ctx, feed); err != nil {
+			log.Error().Err(err).Msgf("Failed to read ics feed %s", feed)
+		}
+	}
+
+	return nil
+}
+
+func (s *icsStrategy) readFeed(ctx context.Context, feed string) error {
+	body, err := s.fetcher.GetFeed(ctx, feed)
+
+	if err != nil {
+		return err
+	}
+
+	parsed, err := ical.Parse(strings.NewReader(body), s.tz)
+
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	occurrences, err := ical.Expand(parsed, now, now.Add(s.horizon))
+
+	if err != nil {
+		return err
+	}
+
+	log.Info().Msgf("Found %d upcoming events in %s", len(occurrences), feed)
+
+	for _, occurrence := range occurrences {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		event := s.toCalendarEvent(feed, occurrence)
+		log.Info().Msgf("Pushing event %s", event.ID)
+		s.channel <- event
+	}
+
+	return nil
+}
+
+func (s *icsStrategy) toCalendarEvent(feed string, occurrence ical.Event) models.CalendarEvent {
+	var venue models.Venue
+
+	location := occurrence.Location
+
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		venue.Online = true
+	} else if location != "" {
+		venue = extractors.VenueFromText("", location)
+	}
+
+	venue.Latitude = occurrence.Latitude
+	venue.Longitude = occurrence.Longitude
+
+	if location == "" {
+		location = s.config.PrettyLocation
+	}
+
+	originURL := occurrence.URL
+
+	if originURL == "" {
+		originURL = feed
+	}
+
+	metadata := map[string]string{
+		"ics-feed":     feed,
+		"ics-uid":      occurrence.UID,
+		"ics-sequence": strconv.Itoa(occurrence.Sequence),
+		"all-day":      strconv.FormatBool(occurrence.AllDay),
+	}
+
+	if !occurrence.RecurrenceID.IsZero() {
+		metadata["ics-recurrence-id"] = occurrence.RecurrenceID.Format(time.RFC3339)
+	}
+
+	// Many calendars only mark a cancellation in the summary.
+	status := extractors.StatusFromTitle(occurrence.Summary)
+
+	if occurrence.Status == "CANCELLED" {
+		status = models.StatusCancelled
+	} else if status == "" {
+		status = models.StatusScheduled
+	}
+
+	event := models.CalendarEvent{
+		Name:        occurrence.Summary,
+		Location:    location,
+		Status:      status,
+		Venue:       venue,
+		Tags:        slices.Concat(s.tags, occurrence.Categories),
+		ImageURL:    occurrence.Image,
+		OriginURL:   originURL,
+		Description: occurrence.Description,
+		StartTime:   occurrence.Start,
+		EndTime:     occurrence.End,
+		Metadata:    metadata,
+	}
+	// Overridden occurrences keep the time they replace as their identity.
+	scheduled := occurrence.Start
+
+	if !occurrence.RecurrenceID.IsZero() {
+		scheduled = occurrence.RecurrenceID
+	}
+
+	util.SetIdentity(&event, util.FeedSource("ics", feed), util.ICSSourceID(occurrence.UID, scheduled))
+
+	return event
+}
@@ -1,12 +1,15 @@
 package jobs
 
 import (
+	"celeve/browser"
 	"celeve/config"
 	"celeve/extractors"
+	"celeve/fetcher"
 	"celeve/models"
 	"celeve/util"
 	"celeve/util/fsm"
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
@@ -15,69 +18,53 @@ import (
 	"strings"
 	"time"
 
+	"github.com/PuerkitoBio/goquery"
 	"github.com/chromedp/chromedp"
 	"github.com/rs/zerolog/log"
 )
 
 type lumaStrategy struct {
+	lifecycle
+	schedule      *schedule
 	config        config.LumaStrategyConfig
-	userAgent     string
 	channel       chan models.CalendarEvent
 	tags          []string
 	url           string
 	pattern       *regexp.Regexp
 	regionPattern *regexp.Regexp
-	opts          []chromedp.ExecAllocatorOption
+	pool          *browser.Pool
+	fetcher       *fetcher.Fetcher
 }
 
-func NewLumaStrategy(params config.LumaStrategyConfig, calendarChan chan models.CalendarEvent, opts []chromedp.ExecAllocatorOption) (Job, error) {
+func NewLumaStrategy(params config.LumaStrategyConfig, calendarChan chan models.CalendarEvent, pool *browser.Pool, fetcher *fetcher.Fetcher) (Job, error) {
 	pattern := `^/[a-z0-9-]+$`
 	regionPattern := `^/` + params.Region + `$`
+	sched, err := newSchedule(params.Schedule)
+
+	if err != nil {
+		return nil, err
+	}
 
 	return &lumaStrategy{
+		schedule:      sched,
 		config:        params,
-		userAgent:     config.Get().UserAgent,
 		channel:       calendarChan,
 		tags:          append(params.Tags, "luma"),
 		url:           fmt.Sprintf("https://lu.ma/%s", params.Region),
 		pattern:       regexp.MustCompile(pattern),
 		regionPattern: regexp.MustCompile(regionPattern),
-		opts:          opts,
+		pool:          pool,
+		fetcher:       fetcher,
 	}, nil
 }
 
-func (s *lumaStrategy) Stop() error {
-	return nil
+func (s *lumaStrategy) Start(ctx context.Context) {
+	runSchedule(s.begin(ctx), "Luma strategy", s.schedule, s.perform)
 }
 
-func (s *lumaStrategy) Start() {
-	ticker := time.NewTicker(config.Get().JobInterval)
-	defer ticker.Stop()
-
-	if err := s.perform(); err != nil {
-		log.Error().Err(err).Msg("Failed luma strategy perform")
-	}
-
-	for range ticker.C {
-		log.Info().Msg("Luma strategy tick")
-
-		if err := s.perform(); err != nil {
-			log.Error().Err(err).Msg("Failed luma strategy perform")
-		}
-	}
-}
-
-func (s *lumaStrategy) perform() error {
+func (s *lumaStrategy) perform(ctx context.Context) error {
 	log.Info().Msg("retrieving luma listing")
 
-	opts := append(
-		chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.UserAgent(s.userAgent),
-	)
-	ctx, cancel := chromedp.NewExecAllocator(context.Background(), opts...)
-
-	defer cancel()
-
 	body, err := s.getLumaBody(ctx)
 
 	if err != nil {
@@ -95,21 +82,65 @@ func (s *lumaStrategy) perform() error {
 	log.Info().Msg("Retrieving luma events")
 
 	for _, url := range urls {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
 		log.Info().Msgf("Extracting url: %s", url)
-		s.extractEvent(url)
+		s.extractEvent(ctx, url)
 	}
 
 	return nil
 }
 
+// getLumaBody saves the region's event list to a temp file, fetched over
+// plain HTTP when it is server-rendered and rendered in a browser otherwise.
 func (s *lumaStrategy) getLumaBody(ctx context.Context) (*os.File, error) {
+	body, err := s.fetchLumaBody(ctx)
+
+	if err == nil {
+		return body, nil
+	}
+
+	log.Info().Err(err).Msg("Falling back to browser for luma listing")
+
+	return s.renderLumaBody(ctx)
+}
+
+func (s *lumaStrategy) fetchLumaBody(ctx context.Context) (*os.File, error) {
+	doc, err := s.fetcher.GetDocument(ctx, s.url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	events := doc.Find(".events").First()
+
+	if events.Find("a[href]").Length() == 0 {
+		return nil, errors.New("no event links in server-rendered listing")
+	}
+
+	htmlContent, err := goquery.OuterHtml(events)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return util.SaveHtmlBody("lumalisting", htmlContent)
+}
+
+func (s *lumaStrategy) renderLumaBody(ctx context.Context) (*os.File, error) {
 	var htmlContent string
 
-	ctx, cancel := chromedp.NewContext(ctx)
+	ctx, cancel, err := s.pool.Tab(ctx)
+
+	if err != nil {
+		return nil, err
+	}
 
 	defer cancel()
 
-	err := chromedp.Run(ctx,
+	err = chromedp.Run(ctx,
 		chromedp.Navigate(s.url),
 		chromedp.WaitReady("body"),
 		chromedp.Evaluate(`window.scrollTo(0, document.body.scrollHeight);`, nil),
@@ -171,9 +202,9 @@ func (s *lumaStrategy) extractLumaUrls(lumaPage *os.File) ([]string, error) {
 	return slices.Compact(result), nil
 }
 
-func (s *lumaStrategy) extractEvent(url string) {
-	extractor := extractors.NewLumaExtractor(url, s.config.PrettyLocation, s.config.Tags, s.config.Timezone, s.opts)
-	event, err := extractor.GetEvent()
+func (s *lumaStrategy) extractEvent(ctx context.Context, url string) {
+	extractor := extractors.NewLumaExtractor(url, s.config.PrettyLocation, s.config.Tags, s.config.Timezone, s.pool, s.fetcher)
+	event, err := extractor.GetEvent(ctx)
 
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to get luma event")
@@ -0,0 +1,300 @@
+package jobs
+
+import (
+	"celeve/browser"
+	"celeve/config"
+	"celeve/fetcher"
+	"celeve/models"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// JobStatus reports the health of a managed job. Failures counts panics since
+// the job last ran cleanly; TotalFailures never resets.
+type JobStatus struct {
+	Key           string
+	Running       bool
+	Quarantined   bool
+	Failures      int
+	TotalFailures int
+	LastError     string
+	LastFailure   time.Time
+}
+
+// Manager owns the running jobs. Strategies are keyed by their full config,
+// so Sync only starts and stops the entries that actually changed.
+type Manager struct {
+	ctx          context.Context
+	mu           sync.Mutex
+	wg           sync.WaitGroup
+	running      map[string]Job
+	status       map[string]*JobStatus
+	calendarChan chan models.CalendarEvent
+	pool         *browser.Pool
+	fetcher      *fetcher.Fetcher
+}
+
+// NewManager creates a manager whose jobs all run under ctx; cancelling it
+// shuts every job down.
+func NewManager(ctx context.Context, calendarChan chan models.CalendarEvent, pool *browser.Pool, fetcher *fetcher.Fetcher) *Manager {
+	return &Manager{
+		ctx:          ctx,
+		running:      make(map[string]Job),
+		status:       make(map[string]*JobStatus),
+		calendarChan: calendarChan,
+		pool:         pool,
+		fetcher:      fetcher,
+	}
+}
+
+// Add starts a job that isn't driven by the extractor config, such as the
+// processor.
+func (m *Manager) Add(key string, job Job) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.start(key, job)
+}
+
+// Sync makes the running strategies match conf. Nothing is started or stopped
+// if any new strategy fails to build.
+func (m *Manager) Sync(conf config.ExtractorConfig) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	desired := make(map[string]bool)
+	created := make(map[string]Job)
+
+	build := func(key string, newJob func() (Job, error)) error {
+		desired[key] = true
+
+		if _, ok := m.running[key]; ok {
+			return nil
+		}
+
+		job, err := newJob()
+
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+
+		created[key] = job
+
+		return nil
+	}
+
+	for _, c := range conf.Meetup {
+		if err := build(strategyKey("meetup", c), func() (Job, error) {
+			return NewMeetupStrategy(c, m.calendarChan, m.pool, m.fetcher)
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range conf.Eventbrite {
+		if err := build(strategyKey("eventbrite", c), func() (Job, error) {
+			return NewEventbriteStrategy(c, m.calendarChan, m.pool, m.fetcher)
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range conf.Luma {
+		if err := build(strategyKey("luma", c), func() (Job, error) {
+			return NewLumaStrategy(c, m.calendarChan, m.pool, m.fetcher)
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range conf.ICS {
+		if err := build(strategyKey("ics", c), func() (Job, error) {
+			return NewICSStrategy(c, m.calendarChan, m.fetcher)
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range conf.RSS {
+		if err := build(strategyKey("rss", c), func() (Job, error) {
+			return NewRSSStrategy(c, m.calendarChan, m.fetcher)
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range conf.Custom {
+		if err := build(strategyKey("custom", c), func() (Job, error) {
+			return NewCustomStrategy(c, m.calendarChan, m.pool, m.fetcher)
+		}); err != nil {
+			return err
+		}
+	}
+
+	for key, job := range m.running {
+		if !desired[key] && isStrategyKey(key) {
+			m.stop(key, job)
+		}
+	}
+
+	for key, job := range created {
+		m.start(key, job)
+	}
+
+	return nil
+}
+
+func (m *Manager) start(key string, job Job) {
+	log.Info().Msgf("Starting job %s", key)
+	m.running[key] = job
+	m.status[key] = &JobStatus{Key: key, Running: true}
+	m.wg.Add(1)
+	go m.execute(key, job)
+}
+
+func (m *Manager) stop(key string, job Job) {
+	log.Info().Msgf("Stopping job %s", key)
+	delete(m.running, key)
+	delete(m.status, key)
+
+	if err := job.Stop(); err != nil {
+		log.Error().Err(err).Msgf("Failed to stop job %s", key)
+	}
+}
+
+func (m *Manager) isRunning(key string, job Job) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.running[key] == job
+}
+
+// Wait blocks until every job has returned, which happens once the manager's
+// context is cancelled.
+func (m *Manager) Wait() {
+	m.wg.Wait()
+}
+
+// Statuses returns a snapshot of every job's health, sorted by key.
+func (m *Manager) Statuses() []JobStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	statuses := make([]JobStatus, 0, len(m.status))
+
+	for _, status := range m.status {
+		statuses = append(statuses, *status)
+	}
+
+	slices.SortFunc(statuses, func(a, b JobStatus) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	return statuses
+}
+
+// execute restarts a job after a panic until it is stopped. A Start that
+// returns normally has been stopped. Restarts back off exponentially, and a
+// job that fails JobMaxFailures times in a row is quarantined: it stays
+// registered, so Statuses reports it, but is not restarted until its config
+// changes.
+func (m *Manager) execute(key string, job Job) {
+	defer m.wg.Done()
+
+	conf := config.Get()
+
+	for m.ctx.Err() == nil && m.isRunning(key, job) {
+		started := time.Now()
+		err := runRecovered(m.ctx, job.Start)
+
+		if err == nil {
+			return
+		}
+
+		// A job that ran for a while before failing isn't crash looping.
+		if time.Since(started) >= conf.JobRestartBackoffMax {
+			m.updateStatus(key, job, func(status *JobStatus) {
+				status.Failures = 0
+			})
+		}
+
+		var failures int
+		var quarantined bool
+
+		m.updateStatus(key, job, func(status *JobStatus) {
+			status.Failures++
+			status.TotalFailures++
+			status.LastError = err.Error()
+			status.LastFailure = time.Now()
+			status.Quarantined = status.Failures >= conf.JobMaxFailures
+			status.Running = !status.Quarantined
+			failures = status.Failures
+			quarantined = status.Quarantined
+		})
+
+		if quarantined {
+			log.Error().Err(err).Msgf("Job %s quarantined after %d consecutive failures", key, failures)
+			return
+		}
+
+		delay := restartBackoff(failures, conf.JobRestartBackoff, conf.JobRestartBackoffMax)
+		log.Error().Err(err).Msgf("Job %s failed %d times in a row, restarting in %s", key, failures, delay)
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-m.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+func (m *Manager) updateStatus(key string, job Job, fn func(*JobStatus)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if status, ok := m.status[key]; ok && m.running[key] == job {
+		fn(status)
+	}
+}
+
+// restartBackoff doubles the delay for every consecutive failure, capped at max.
+func restartBackoff(failures int, base, max time.Duration) time.Duration {
+	delay := base
+
+	for i := 1; i < failures && delay < max; i++ {
+		delay *= 2
+	}
+
+	return min(delay, max)
+}
+
+func runRecovered(ctx context.Context, fn func(context.Context)) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	fn(ctx)
+
+	return nil
+}
+
+const strategyKeyPrefix = "strategy:"
+
+func strategyKey(source string, c any) string {
+	return fmt.Sprintf("%s%s %+v", strategyKeyPrefix, source, c)
+}
+
+func isStrategyKey(key string) bool {
+	return strings.HasPrefix(key, strategyKeyPrefix)
+}
@@ -1,12 +1,15 @@
 package jobs
 
 import (
+	"celeve/browser"
 	"celeve/config"
 	"celeve/extractors"
+	"celeve/fetcher"
 	"celeve/models"
 	"celeve/util"
 	"celeve/util/fsm"
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
@@ -22,31 +25,42 @@ import (
 const meetupURLPrefix = "https://www.meetup.com/find/"
 const urlPattern = `^\/[^\/]+\/events\/\d+\/$`
 
+var meetupEventLinkPattern = regexp.MustCompile(`meetup\.com\/[^\/"']+\/events\/\d+`)
+
 type meetupStrategy struct {
+	lifecycle
+	schedule       *schedule
 	config         config.MeetupStrategyConfig
 	url            string
-	userAgent      string
 	channel        chan models.CalendarEvent
 	prettyLocation string
 	tags           []string
-	opts           []chromedp.ExecAllocatorOption
+	pool           *browser.Pool
+	fetcher        *fetcher.Fetcher
 }
 
-func NewMeetupStrategy(params config.MeetupStrategyConfig, calendarChan chan models.CalendarEvent, opts []chromedp.ExecAllocatorOption) (Job, error) {
+func NewMeetupStrategy(params config.MeetupStrategyConfig, calendarChan chan models.CalendarEvent, pool *browser.Pool, fetcher *fetcher.Fetcher) (Job, error) {
 	url, err := assembleMeetupURL(params.Query, params.Country, params.Province, params.City)
 
 	if err != nil {
 		return nil, err
 	}
 
+	sched, err := newSchedule(params.Schedule)
+
+	if err != nil {
+		return nil, err
+	}
+
 	return &meetupStrategy{
+		schedule:       sched,
 		config:         params,
 		url:            url,
-		userAgent:      config.Get().UserAgent,
 		channel:        calendarChan,
 		prettyLocation: getPrettyLocationName(params.City, params.Province, params.Country),
 		tags:           append(params.Tags, "meetup"),
-		opts:           opts,
+		pool:           pool,
+		fetcher:        fetcher,
 	}, nil
 }
 
@@ -61,30 +75,17 @@ func getPrettyLocationName(country, province, city string) string {
 func (s *meetupStrategy) Stop() error {
 	log.Info().Msg("Stopping meetup extractor")
 
-	return nil
+	return s.lifecycle.Stop()
 }
 
-func (s *meetupStrategy) Start() {
-	ticker := time.NewTicker(config.Get().JobInterval)
-	defer ticker.Stop()
-
-	if err := s.perform(); err != nil {
-		log.Error().Err(err).Msg("Meetup strategy perform failed")
-	}
-
-	for range ticker.C {
-		log.Info().Msg("Meetup strategy tick")
-
-		if err := s.perform(); err != nil {
-			log.Error().Err(err).Msg("Meetup strategy perform failed")
-		}
-	}
+func (s *meetupStrategy) Start(ctx context.Context) {
+	runSchedule(s.begin(ctx), "Meetup strategy", s.schedule, s.perform)
 }
 
-func (s *meetupStrategy) perform() error {
+func (s *meetupStrategy) perform(ctx context.Context) error {
 	log.Info().Msg("Retrieving meetup listing")
 
-	f, err := s.getMeetupListingBody()
+	f, err := s.getMeetupListingBody(ctx)
 
 	if err != nil {
 		log.Info().Msg("Error was not nil")
@@ -104,16 +105,20 @@ func (s *meetupStrategy) perform() error {
 	log.Info().Msg("Retrieving meetups")
 
 	for _, url := range urls {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
 		log.Info().Msgf("Extracting url: %s", url)
-		s.extractEvent(url)
+		s.extractEvent(ctx, url)
 	}
 
 	return nil
 }
 
-func (s *meetupStrategy) extractEvent(url string) {
-	extractor := extractors.NewMeetupExtractor(url, s.prettyLocation, s.tags, s.config.Timezone)
-	evt, err := extractor.GetEvent()
+func (s *meetupStrategy) extractEvent(ctx context.Context, url string) {
+	extractor := extractors.NewMeetupExtractor(url, s.prettyLocation, s.tags, s.config.Timezone, s.pool, s.fetcher)
+	evt, err := extractor.GetEvent(ctx)
 
 	if err != nil {
 		log.Info().Msg(err.Error())
@@ -165,18 +170,47 @@ func (s *meetupStrategy) extractMeetupUrls(meetupPage *os.File) ([]string, error
 	return slices.Compact(result), nil
 }
 
-func (s *meetupStrategy) getMeetupListingBody() (*os.File, error) {
-	var htmlContent string
+// getMeetupListingBody saves the search results to a temp file. The plain
+// HTTP response is used when it already links to events; otherwise the page
+// is rendered and scrolled in a browser.
+func (s *meetupStrategy) getMeetupListingBody(ctx context.Context) (*os.File, error) {
+	body, err := s.fetchMeetupListingBody(ctx)
 
-	ctx, cancel := chromedp.NewExecAllocator(context.Background(), s.opts...)
+	if err == nil {
+		return body, nil
+	}
 
-	defer cancel()
+	log.Info().Err(err).Msg("Falling back to browser for meetup listing")
 
-	ctx, cancel = chromedp.NewContext(ctx)
+	return s.renderMeetupListingBody(ctx)
+}
+
+func (s *meetupStrategy) fetchMeetupListingBody(ctx context.Context) (*os.File, error) {
+	htmlContent, err := s.fetcher.GetHTML(ctx, s.url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !meetupEventLinkPattern.MatchString(htmlContent) {
+		return nil, errors.New("no event links in server-rendered listing")
+	}
+
+	return util.SaveHtmlBody("meetuplisting", htmlContent)
+}
+
+func (s *meetupStrategy) renderMeetupListingBody(ctx context.Context) (*os.File, error) {
+	var htmlContent string
+
+	ctx, cancel, err := s.pool.Tab(ctx)
+
+	if err != nil {
+		return nil, err
+	}
 
 	defer cancel()
 
-	err := chromedp.Run(ctx,
+	err = chromedp.Run(ctx,
 		chromedp.Navigate(s.url),
 		chromedp.WaitReady("body"),
 		chromedp.Evaluate(`window.scrollTo(0, document.body.scrollHeight);`, nil),
@@ -2,8 +2,13 @@ package jobs
 
 import (
 	"celeve/config"
+	"celeve/extractors"
+	"celeve/fetcher"
 	"celeve/gateways"
+	"celeve/geocoder"
 	"celeve/models"
+	"celeve/thumbnails"
+	"context"
 	"embed"
 	"encoding/json"
 	"slices"
@@ -13,62 +18,74 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// statusCheckBatch caps how many event pages one run re-checks.
+const statusCheckBatch = 50
+
 //go:embed keywords
 var keywords embed.FS
 
 type processorJob struct {
-	tags   map[string][]string
-	sqlite gateways.SqliteGateway
+	lifecycle
+	schedule *schedule
+	tags     map[string][]string
+	sqlite   gateways.SqliteGateway
+	geocoder *geocoder.Geocoder
+	images   *thumbnails.Cache
+	fetcher  *fetcher.Fetcher
 }
 
-func NewProcessorJob(sqlite gateways.SqliteGateway) (Job, error) {
+// NewProcessorJob returns the job that tags, geocodes and, when images is not
+// nil, thumbnails newly stored events, and finds duplicates among and
+// re-checks the status of upcoming ones.
+func NewProcessorJob(sqlite gateways.SqliteGateway, geocoder *geocoder.Geocoder, images *thumbnails.Cache, fetcher *fetcher.Fetcher) (Job, error) {
 	tags, err := getTags()
 
 	if err != nil {
 		return nil, err
 	}
 
-	return &processorJob{
-		tags:   tags,
-		sqlite: sqlite,
-	}, nil
-}
-
-func (s *processorJob) Start() {
-	ticker := time.NewTicker(config.Get().JobInterval)
-	defer ticker.Stop()
+	sched, err := newSchedule(config.Get().ProcessorSchedule)
 
-	if err := s.perform(); err != nil {
-		log.Error().Err(err).Msg("Processor perform failed")
+	if err != nil {
+		return nil, err
 	}
 
-	for range ticker.C {
-		log.Info().Msg("Processor job tick")
-
-		if err := s.perform(); err != nil {
-			log.Error().Err(err).Msg("Processor perform failed")
-		}
-	}
+	return &processorJob{
+		schedule: sched,
+		tags:     tags,
+		sqlite:   sqlite,
+		geocoder: geocoder,
+		images:   images,
+		fetcher:  fetcher,
+	}, nil
 }
 
-func (s *processorJob) Stop() error {
-	return nil
+func (s *processorJob) Start(ctx context.Context) {
+	runSchedule(s.begin(ctx), "Processor job", s.schedule, s.perform)
 }
 
-func (s *processorJob) perform() error {
+func (s *processorJob) perform(ctx context.Context) error {
 	events, err := s.sqlite.GetEventsForProcessing()
 
-	if len(events) == 0 {
-		return nil
-	}
-
 	if err != nil {
 		return err
 	}
 
-	s.hydrateTags(events)
+	if len(events) > 0 {
+		s.hydrateTags(events)
+		s.geocode(events)
+		s.addThumbnails(ctx, events)
 
-	return s.sqlite.BulkProcessEvents(events)
+		if err := s.sqlite.BulkProcessEvents(events); err != nil {
+			return err
+		}
+	}
+
+	if err := s.dedupe(); err != nil {
+		return err
+	}
+
+	return s.checkStatuses(ctx)
 }
 
 func (s *processorJob) hydrateTags(events []*models.CalendarEvent) {
@@ -88,6 +105,88 @@ func (s *processorJob) hydrateTags(events []*models.CalendarEvent) {
 	}
 }
 
+// geocode fills in coordinates for venues whose source didn't provide them.
+func (s *processorJob) geocode(events []*models.CalendarEvent) {
+	for _, event := range events {
+		if event.Venue.Latitude != nil {
+			continue
+		}
+
+		if latitude, longitude, ok := s.geocoder.Geocode(event.Venue); ok {
+			event.Venue.Latitude = &latitude
+			event.Venue.Longitude = &longitude
+		}
+	}
+}
+
+// addThumbnails caches a resized copy of each event's image. Events whose
+// image can't be fetched are left without one.
+func (s *processorJob) addThumbnails(ctx context.Context, events []*models.CalendarEvent) {
+	if s.images == nil {
+		return
+	}
+
+	for _, event := range events {
+		if event.ImageURL == "" || event.Thumbnail != "" || ctx.Err() != nil {
+			continue
+		}
+
+		name, err := s.images.Add(ctx, event.ImageURL)
+
+		if err != nil {
+			log.Warn().Err(err).Msgf("Unable to create thumbnail for %s", event.ID)
+			continue
+		}
+
+		event.Thumbnail = name
+	}
+}
+
+// checkStatuses re-reads the pages of upcoming events that haven't been
+// checked for a while. Pages that say nothing keep the status they had. ICS
+// events are skipped, since their feed is re-read on every crawl anyway.
+func (s *processorJob) checkStatuses(ctx context.Context) error {
+	interval := config.Get().StatusCheckInterval
+
+	if interval <= 0 {
+		return nil
+	}
+
+	events, err := s.sqlite.GetEventsForStatusCheck(time.Now().Add(-interval), statusCheckBatch)
+
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		status := event.Status
+
+		if event.Metadata["ics-feed"] == "" {
+			checked, err := extractors.CheckStatus(ctx, s.fetcher, event.OriginURL)
+
+			if err != nil {
+				log.Warn().Err(err).Msgf("Unable to check status of %s", event.ID)
+			} else if checked != "" {
+				status = checked
+			}
+		}
+
+		if status != event.Status {
+			log.Info().Msgf("Event %s is now %s", event.ID, status)
+		}
+
+		if err := s.sqlite.UpdateStatus(event.ID, status, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (s *processorJob) findTags(str string) []string {
 	var matchedKeys []string
 
@@ -0,0 +1,102 @@
+package jobs
+
+import (
+	"celeve/config"
+	"celeve/extractors"
+	"celeve/fetcher"
+	"celeve/models"
+	"celeve/util/feed"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// rssStrategy reads RSS and Atom feeds from organizers that only announce
+// events in blog or news posts.
+type rssStrategy struct {
+	lifecycle
+	schedule *schedule
+	config   config.RSSStrategyConfig
+	channel  chan models.CalendarEvent
+	tags     []string
+	fetcher  *fetcher.Fetcher
+}
+
+func NewRSSStrategy(params config.RSSStrategyConfig, calendarChan chan models.CalendarEvent, fetcher *fetcher.Fetcher) (Job, error) {
+	sched, err := newSchedule(params.Schedule)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := time.LoadLocation(params.Timezone); err != nil {
+		return nil, err
+	}
+
+	return &rssStrategy{
+		schedule: sched,
+		config:   params,
+		channel:  calendarChan,
+		tags:     append(params.Tags, "rss"),
+		fetcher:  fetcher,
+	}, nil
+}
+
+func (s *rssStrategy) Start(ctx context.Context) {
+	runSchedule(s.begin(ctx), fmt.Sprintf("RSS strategy %s", s.config.Name), s.schedule, s.perform)
+}
+
+func (s *rssStrategy) perform(ctx context.Context) error {
+	for _, url := range s.config.URLs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		log.Info().Msgf("Retrieving feed %s", url)
+
+		if err := s.readFeed(ctx, url); err != nil {
+			log.Error().Err(err).Msgf("Failed to read feed %s", url)
+		}
+	}
+
+	return nil
+}
+
+func (s *rssStrategy) readFeed(ctx context.Context, url string) error {
+	body, err := s.fetcher.GetFeed(ctx, url)
+
+	if err != nil {
+		return err
+	}
+
+	entries, err := feed.Parse([]byte(body))
+
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if entry.Link == "" {
+			continue
+		}
+
+		extractor := extractors.NewFeedEntryExtractor(entry, url, s.config.PrettyLocation, s.tags, s.config.Timezone)
+		event, err := extractor.GetEvent(ctx)
+
+		if err != nil {
+			log.Debug().Err(err).Msgf("Skipping feed entry %s", entry.Link)
+			continue
+		}
+
+		log.Info().Msgf("Pushing event %s", event.ID)
+		s.channel <- *event
+	}
+
+	return nil
+}
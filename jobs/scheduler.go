@@ -0,0 +1,155 @@
+package jobs
+
+import (
+	"celeve/browser"
+	"celeve/config"
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog/log"
+)
+
+// schedule decides when a job runs next. It is built from a
+// config.ScheduleConfig and shared by every job's Start loop.
+type schedule struct {
+	cron        cron.Schedule
+	interval    time.Duration
+	jitter      time.Duration
+	loc         *time.Location
+	hasWindow   bool
+	windowStart time.Duration
+	windowEnd   time.Duration
+}
+
+func newSchedule(c config.ScheduleConfig) (*schedule, error) {
+	s := &schedule{
+		interval: c.Interval,
+		jitter:   c.Jitter,
+		loc:      time.Local,
+	}
+
+	if c.Cron != "" {
+		parsed, err := cron.ParseStandard(c.Cron)
+
+		if err != nil {
+			return nil, err
+		}
+
+		s.cron = parsed
+	} else if s.interval == 0 {
+		s.interval = config.Get().JobInterval
+	}
+
+	if c.Timezone != "" {
+		loc, err := time.LoadLocation(c.Timezone)
+
+		if err != nil {
+			return nil, err
+		}
+
+		s.loc = loc
+	}
+
+	if c.ActiveHours != "" {
+		start, end, err := config.ParseActiveHours(c.ActiveHours)
+
+		if err != nil {
+			return nil, err
+		}
+
+		s.hasWindow = true
+		s.windowStart = start
+		s.windowEnd = end
+	}
+
+	return s, nil
+}
+
+// next returns the first run time after now, including jitter and pushed into
+// the active hours window if one is set.
+func (s *schedule) next(now time.Time) time.Time {
+	var t time.Time
+
+	if s.cron != nil {
+		t = s.cron.Next(now.In(s.loc))
+	} else {
+		t = now.Add(s.interval)
+	}
+
+	if s.jitter > 0 {
+		t = t.Add(rand.N(s.jitter))
+	}
+
+	return s.nextActive(t)
+}
+
+// nextActive returns t if it is inside the active hours window, otherwise the
+// start of the next window.
+func (s *schedule) nextActive(t time.Time) time.Time {
+	if !s.hasWindow {
+		return t
+	}
+
+	t = t.In(s.loc)
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.loc)
+	offset := t.Sub(midnight)
+
+	if s.windowStart < s.windowEnd {
+		if offset >= s.windowStart && offset < s.windowEnd {
+			return t
+		}
+
+		if offset < s.windowStart {
+			return midnight.Add(s.windowStart)
+		}
+
+		return midnight.AddDate(0, 0, 1).Add(s.windowStart)
+	}
+
+	// The window wraps past midnight, e.g. 22:00-02:00.
+	if offset >= s.windowStart || offset < s.windowEnd {
+		return t
+	}
+
+	return midnight.Add(s.windowStart)
+}
+
+// runSchedule calls perform according to the schedule until ctx is done.
+// Interval schedules run once immediately, as long as that is within the
+// active hours; cron schedules wait for their first fire time.
+func runSchedule(ctx context.Context, name string, s *schedule, perform func(context.Context) error) {
+	now := time.Now()
+	next := s.nextActive(now)
+
+	if s.cron != nil {
+		next = s.next(now)
+	}
+
+	for {
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		log.Info().Msgf("%s tick", name)
+
+		crawlCtx := browser.WithCrawlStats(ctx)
+
+		if err := perform(crawlCtx); err != nil {
+			log.Error().Err(err).Msgf("%s perform failed", name)
+		}
+
+		if blocked := browser.BlockedRequests(crawlCtx); blocked > 0 {
+			log.Info().Msgf("%s blocked %d requests", name, blocked)
+		}
+
+		next = s.next(time.Now())
+		log.Info().Msgf("%s next run at %s", name, next.Format(time.RFC3339))
+	}
+}
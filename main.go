@@ -1,24 +1,32 @@
 package main
 
 import (
+	"celeve/browser"
 	"celeve/config"
 	"celeve/controllers"
+	"celeve/fetcher"
 	"celeve/gateways"
+	"celeve/geocoder"
 	"celeve/jobs"
 	"celeve/models"
-	"celeve/util"
+	"celeve/thumbnails"
+	"context"
+	"flag"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/chromedp/chromedp"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
-func startJobServer(gateway gateways.SqliteGateway) {
-	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
-	conf := config.NewConfig()
+const shutdownTimeout = 30 * time.Second
+
+func newJobManager(ctx context.Context, fetcher *fetcher.Fetcher) (*jobs.Manager, *browser.Pool, chan models.CalendarEvent) {
 	calendarChan := make(chan models.CalendarEvent)
-	var jobsToRun []jobs.Job
 	opts := append(
 		chromedp.DefaultExecAllocatorOptions[:],
 		chromedp.UserAgent(config.Get().UserAgent),
@@ -31,87 +39,93 @@ func startJobServer(gateway gateways.SqliteGateway) {
 		)
 	}
 
-	/////////////////////////////////////////////////////////////////////////
-	// Meetup
-	/////////////////////////////////////////////////////////////////////////
+	if config.Get().RemoteBrowserURL != "" && config.Get().HTTPProxy != "" {
+		log.Warn().Msg("http_proxy is not applied to a remote browser, configure it on the remote Chrome instead")
+	}
 
-	for _, meetupConfig := range conf.Extractors.Meetup {
-		job, err := jobs.NewMeetupStrategy(meetupConfig, calendarChan, opts)
+	pool := browser.NewPool(ctx, browser.Options{
+		Size:           config.Get().BrowserPoolSize,
+		MaxTabs:        config.Get().BrowserMaxTabs,
+		ExecOptions:    opts,
+		RemoteURL:      config.Get().RemoteBrowserURL,
+		UserAgent:      config.Get().UserAgent,
+		HealthInterval: config.Get().RemoteBrowserHealthInterval,
+		Block: browser.Blocking{
+			ResourceTypes: config.Get().BlockResourceTypes,
+			URLPatterns:   config.Get().BlockURLPatterns,
+		},
+	})
 
-		if err != nil {
-			log.Fatal().Err(err)
-		}
+	return jobs.NewManager(ctx, calendarChan, pool, fetcher), pool, calendarChan
+}
 
-		jobsToRun = append(jobsToRun, job)
-	}
+// startJobServer runs the jobs until ctx is cancelled, then waits for them to
+// return and drains whatever they already produced into the gateway.
+func startJobServer(ctx context.Context, gateway gateways.SqliteGateway, manager *jobs.Manager, pool *browser.Pool, fetcher *fetcher.Fetcher, images *thumbnails.Cache, calendarChan chan models.CalendarEvent) {
+	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
+	conf := config.Get()
 
 	/////////////////////////////////////////////////////////////////////////
-	// Eventbrite
+	// Strategies
 	/////////////////////////////////////////////////////////////////////////
 
-	for _, ebConfig := range conf.Extractors.Eventbrite {
-		job, err := jobs.NewEventbriteStrategy(ebConfig, calendarChan, opts)
-
-		if err != nil {
-			log.Fatal().Err(err)
-		}
-
-		jobsToRun = append(jobsToRun, job)
+	if err := manager.Sync(conf.Extractors); err != nil {
+		log.Fatal().Err(err).Msg("Unable to start strategies")
 	}
+
 	/////////////////////////////////////////////////////////////////////////
-	// Luma
+	// Processor
 	/////////////////////////////////////////////////////////////////////////
 
-	for _, lumaConfig := range conf.Extractors.Luma {
-		job, err := jobs.NewLumaStrategy(lumaConfig, calendarChan, opts)
+	if conf.EnableProcessorJob {
+		gazetteer, err := geocoder.New(conf.GeocoderDataPath)
 
 		if err != nil {
-			log.Fatal().Err(err)
+			log.Fatal().Err(err).Msg("Unable to load geocoder data")
 		}
 
-		jobsToRun = append(jobsToRun, job)
-	}
-
-	/////////////////////////////////////////////////////////////////////////
-	// Processor
-	/////////////////////////////////////////////////////////////////////////
+		if conf.GeocoderDataPath == "" {
+			log.Warn().Msg("geocoder_data_path is not set, so venues are placed at the centre of their city or neighbourhood; set it to a postal code file for radius filters and distance sorting to be useful")
+		}
 
-	if config.Get().EnableProcessorJob {
-		job, err := jobs.NewProcessorJob(gateway)
+		job, err := jobs.NewProcessorJob(gateway, gazetteer, images, fetcher)
 
 		if err != nil {
 			log.Fatal().Err(err)
 		}
 
-		jobsToRun = append(jobsToRun, job)
+		manager.Add("processor", job)
 	}
 
 	/////////////////////////////////////////////////////////////////////////
-	// Start Jobs
+	// Config Reload
 	/////////////////////////////////////////////////////////////////////////
 
-	for _, job := range jobsToRun {
-		go executeJob(job.Start)
-	}
+	go config.Watch(ctx, func(c config.Config) {
+		if err := manager.Sync(c.Extractors); err != nil {
+			log.Error().Err(err).Msg("Unable to apply reloaded extractor config")
+		}
+	})
 
 	/////////////////////////////////////////////////////////////////////////
 	// Process Events
 	/////////////////////////////////////////////////////////////////////////
 
+	go func() {
+		<-ctx.Done()
+		log.Info().Msg("Waiting for jobs to stop")
+		manager.Wait()
+		pool.Close()
+		close(calendarChan)
+	}()
+
 	for event := range calendarChan {
-		gateway.UpsertEvent(event)
+		if err := gateway.UpsertEvent(event); err != nil {
+			log.Error().Err(err).Msgf("Failed to save event %s", event.ID)
+		}
 	}
-}
 
-func executeJob(fn func()) {
-	for {
-		func() {
-			defer func() {
-				util.LogRecover()
-			}()
-			fn()
-		}()
-	}
+	log.Info().Msg("Job server stopped")
 }
 
 func enableCORS(next http.Handler) http.Handler {
@@ -129,7 +143,7 @@ func enableCORS(next http.Handler) http.Handler {
 	})
 }
 
-func startHttpServer(gateway gateways.SqliteGateway) {
+func startHttpServer(gateway gateways.SqliteGateway, manager *jobs.Manager, images *thumbnails.Cache) *http.Server {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
@@ -138,23 +152,102 @@ func startHttpServer(gateway gateways.SqliteGateway) {
 	mux.HandleFunc("/event", func(w http.ResponseWriter, r *http.Request) {
 		controllers.GetEvent(gateway, w, r)
 	})
+	mux.HandleFunc("/event/history", func(w http.ResponseWriter, r *http.Request) {
+		controllers.GetEventHistory(gateway, w, r)
+	})
 	mux.HandleFunc("/tags", func(w http.ResponseWriter, r *http.Request) {
 		controllers.GetTags(gateway, w, r)
 	})
+	mux.HandleFunc("/jobs", func(w http.ResponseWriter, r *http.Request) {
+		controllers.GetJobs(manager, w, r)
+	})
+
+	if images != nil {
+		mux.Handle("/thumbnails/", http.StripPrefix("/thumbnails/", images.Handler()))
+	}
+
+	server := &http.Server{
+		Addr:    config.Get().HTTPServerAddress,
+		Handler: enableCORS(mux),
+	}
 
-	handler := enableCORS(mux)
+	go func() {
+		log.Info().Msgf("HTTP server listening on %s", server.Addr)
 
-	log.Info().Msgf("HTTP server listening on %s", config.Get().HTTPServerAddress)
-	http.ListenAndServe(config.Get().HTTPServerAddress, handler)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal().Err(err).Msg("HTTP server failed")
+		}
+	}()
+
+	return server
 }
 
 func main() {
+	configPath := flag.String("config", os.Getenv(config.EnvConfigPath), "path to a YAML or JSON config file")
+	flag.Parse()
+
+	if err := config.Load(*configPath); err != nil {
+		log.Fatal().Err(err).Msg("Unable to load config")
+	}
+
+	if flag.Arg(0) == "migrate" {
+		if err := runMigrate(flag.Args()[1:]); err != nil {
+			log.Fatal().Err(err).Msg("Migration command failed")
+		}
+
+		return
+	}
+
 	gateway, err := gateways.NewEventSqliteGateway()
 
 	if err != nil {
 		log.Fatal().Err(err)
 	}
 
-	go startJobServer(gateway)
-	startHttpServer(gateway)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fetcher, err := fetcher.New(config.Get().UserAgent, config.Get().HTTPProxy)
+
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to create HTTP fetcher")
+	}
+
+	var images *thumbnails.Cache
+
+	if conf := config.Get().ImageCache; conf.Path != "" {
+		if images, err = thumbnails.New(conf.Path, conf.Width, fetcher); err != nil {
+			log.Fatal().Err(err).Msg("Unable to create image cache")
+		}
+	}
+
+	manager, pool, calendarChan := newJobManager(ctx, fetcher)
+	jobsDone := make(chan struct{})
+
+	go func() {
+		startJobServer(ctx, gateway, manager, pool, fetcher, images, calendarChan)
+		close(jobsDone)
+	}()
+
+	server := startHttpServer(gateway, manager, images)
+
+	<-ctx.Done()
+	log.Info().Msg("Shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("Failed to shut down HTTP server")
+	}
+
+	select {
+	case <-jobsDone:
+	case <-shutdownCtx.Done():
+		log.Error().Msg("Timed out waiting for jobs to stop")
+	}
+
+	if err := gateway.Close(); err != nil {
+		log.Error().Err(err).Msg("Failed to close gateway")
+	}
 }
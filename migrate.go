@@ -0,0 +1,67 @@
+package main
+
+import (
+	"celeve/gateways"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// runMigrate implements "celeve migrate status", which lists the schema
+// migrations and whether the event store has had them, and "celeve migrate
+// up", which applies the pending ones without starting anything else.
+func runMigrate(args []string) error {
+	command := "status"
+
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "status":
+		return printMigrationStatus()
+	case "up":
+		gateway, err := gateways.NewEventSqliteGateway()
+
+		if err != nil {
+			return err
+		}
+
+		return gateway.Close()
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected status or up", command)
+	}
+}
+
+func printMigrationStatus() error {
+	statuses, err := gateways.GetMigrationStatus()
+
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+	pending := 0
+
+	for _, status := range statuses {
+		applied := "pending"
+
+		if status.AppliedAt != nil {
+			applied = status.AppliedAt.Local().Format(time.DateTime)
+		} else {
+			pending++
+		}
+
+		fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, status.Name, applied)
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n%d of %d migrations pending\n", pending, len(statuses))
+
+	return nil
+}
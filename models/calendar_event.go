@@ -2,16 +2,31 @@ package models
 
 import "time"
 
+// CalendarEvent is identified by Source, the site or feed it came from, and
+// SourceID, that source's own ID for it. ContentHash changes whenever the
+// source edits the event. The same event listed on several sources is stored
+// once per source; all but one of those copies have CanonicalID set to the
+// one shown in their place.
 type CalendarEvent struct {
 	ID          string
+	Source      string
+	SourceID    string
+	ContentHash string
 	Name        string
 	StartTime   time.Time
 	EndTime     time.Time
 	Location    string
+	Status      EventStatus
+	Venue       Venue
+	Ticketing   Ticketing
+	ImageURL    string // cover image at the source
+	Thumbnail   string // file name of a resized copy in the image cache
 	Description string
 	OriginURL   string
 	Tags        []string
 	Processed   bool
 	Relevant    bool
 	Metadata    map[string]string
+	CanonicalID string   // the event this one duplicates, if any
+	SourceURLs  []string // OriginURLs of the event and its duplicates
 }
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"reflect"
+	"time"
+)
+
+const (
+	RevisionCreated = "created"
+	RevisionUpdated = "updated"
+)
+
+// EventRevision records one change to an event seen on a crawl.
+type EventRevision struct {
+	EventID     string
+	Kind        string
+	RecordedAt  time.Time
+	ContentHash string
+	Changes     map[string]FieldChange
+}
+
+type FieldChange struct {
+	Old any
+	New any
+}
+
+// sourceFields are the fields a source decides, which are compared when an
+// event is crawled again. The rest are set by the processor.
+var sourceFields = []struct {
+	name  string
+	value func(CalendarEvent) any
+}{
+	{"Name", func(e CalendarEvent) any { return e.Name }},
+	{"StartTime", func(e CalendarEvent) any { return e.StartTime.UTC().Format(time.RFC3339) }},
+	{"EndTime", func(e CalendarEvent) any { return e.EndTime.UTC().Format(time.RFC3339) }},
+	{"Location", func(e CalendarEvent) any { return e.Location }},
+	{"Status", func(e CalendarEvent) any { return e.Status }},
+	{"Venue", func(e CalendarEvent) any { return e.Venue }},
+	{"Ticketing", func(e CalendarEvent) any { return e.Ticketing }},
+	{"ImageURL", func(e CalendarEvent) any { return e.ImageURL }},
+	{"Description", func(e CalendarEvent) any { return e.Description }},
+	{"OriginURL", func(e CalendarEvent) any { return e.OriginURL }},
+}
+
+// DiffEvents lists the source fields that differ between two versions of an
+// event.
+func DiffEvents(before, after CalendarEvent) map[string]FieldChange {
+	changes := make(map[string]FieldChange)
+
+	for _, field := range sourceFields {
+		was, now := field.value(before), field.value(after)
+
+		if !reflect.DeepEqual(was, now) {
+			changes[field.name] = FieldChange{Old: was, New: now}
+		}
+	}
+
+	return changes
+}
@@ -0,0 +1,21 @@
+package models
+
+// EventStatus says whether an event is still going ahead as listed.
+type EventStatus string
+
+const (
+	StatusScheduled   EventStatus = "scheduled"
+	StatusCancelled   EventStatus = "cancelled"
+	StatusPostponed   EventStatus = "postponed"
+	StatusRescheduled EventStatus = "rescheduled"
+	StatusMovedOnline EventStatus = "moved-online"
+)
+
+// EventStatuses lists every status, in the order they're documented.
+var EventStatuses = []EventStatus{
+	StatusScheduled,
+	StatusCancelled,
+	StatusPostponed,
+	StatusRescheduled,
+	StatusMovedOnline,
+}
@@ -0,0 +1,19 @@
+package models
+
+// Ticketing describes what it costs to attend an event. Price is the lowest
+// ticket price and MaxPrice the highest when there is a range; both are nil
+// when the source doesn't say. An event is only Free when the source says so
+// or lists a price of zero.
+type Ticketing struct {
+	Price    *float64
+	MaxPrice *float64
+	Currency string
+	Free     bool
+	SoldOut  bool
+	URL      string
+}
+
+// IsZero reports whether nothing is known about the event's tickets.
+func (t Ticketing) IsZero() bool {
+	return t == Ticketing{}
+}
@@ -0,0 +1,40 @@
+package models
+
+import "strings"
+
+// Venue is where an event takes place. Extractors fill in whatever the source
+// exposes, so any field may be empty; Latitude and Longitude are nil when the
+// location hasn't been geocoded.
+type Venue struct {
+	Name          string
+	StreetAddress string
+	City          string
+	Region        string
+	PostalCode    string
+	Country       string
+	Latitude      *float64
+	Longitude     *float64
+	Online        bool
+}
+
+// IsZero reports whether nothing is known about the venue.
+func (v Venue) IsZero() bool {
+	return v == Venue{}
+}
+
+// String formats the venue on one line, e.g. "The Hall, 1 Main St, New York, NY".
+func (v Venue) String() string {
+	var parts []string
+
+	for _, part := range []string{v.Name, v.StreetAddress, v.City, v.Region, v.PostalCode, v.Country} {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	if len(parts) == 0 && v.Online {
+		return "Online"
+	}
+
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,145 @@
+package thumbnails
+
+import (
+	"bytes"
+	"celeve/fetcher"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const jpegQuality = 80
+
+// maxPixels caps the size of images that are decoded. A few hundred bytes of
+// PNG or GIF can declare an image that needs gigabytes of memory.
+const maxPixels = 40_000_000
+
+// Cache keeps scaled down copies of event images on disk, named after the
+// hash of their contents, so the web UI can show them without hotlinking.
+type Cache struct {
+	dir     string
+	width   int
+	fetcher *fetcher.Fetcher
+}
+
+func New(dir string, width int, fetcher *fetcher.Fetcher) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &Cache{
+		dir:     dir,
+		width:   width,
+		fetcher: fetcher,
+	}, nil
+}
+
+// Add downloads imageURL, scales it to the cache's width and stores it as a
+// JPEG, returning the file name it is served under.
+func (c *Cache) Add(ctx context.Context, imageURL string) (string, error) {
+	body, err := c.fetcher.GetImage(ctx, imageURL)
+
+	if err != nil {
+		return "", err
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(body))
+
+	if err != nil {
+		return "", fmt.Errorf("decode %s: %w", imageURL, err)
+	}
+
+	if config.Width <= 0 || config.Height <= 0 || config.Width > maxPixels/config.Height {
+		return "", fmt.Errorf("decode %s: %dx%d image is too large", imageURL, config.Width, config.Height)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(body))
+
+	if err != nil {
+		return "", fmt.Errorf("decode %s: %w", imageURL, err)
+	}
+
+	var buf bytes.Buffer
+
+	if err := jpeg.Encode(&buf, c.scale(src), &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(buf.Bytes())
+	name := hex.EncodeToString(sum[:]) + ".jpg"
+	path := filepath.Join(c.dir, name)
+
+	if _, err := os.Stat(path); err == nil {
+		return name, nil
+	}
+
+	// Write then rename, so a half-written file is never served.
+	tmp, err := os.CreateTemp(c.dir, ".thumbnail-*")
+
+	if err != nil {
+		return "", err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return "", err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+// scale shrinks src to the cache's width, keeping its aspect ratio. Smaller
+// images are only flattened onto an opaque background.
+func (c *Cache) scale(src image.Image) image.Image {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	if width > c.width {
+		height = max(1, height*c.width/width)
+		width = c.width
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	return dst
+}
+
+// Handler serves the cached files, without directory listings. Their names
+// change with their contents, so clients may cache them forever.
+func (c *Cache) Handler() http.Handler {
+	files := http.FileServer(http.Dir(c.dir))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/") || strings.HasPrefix(filepath.Base(r.URL.Path), ".") {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		files.ServeHTTP(w, r)
+	})
+}
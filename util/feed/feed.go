@@ -0,0 +1,192 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html/charset"
+)
+
+var timeFormats = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02",
+}
+
+// Entry is one item of an RSS feed or entry of an Atom feed.
+type Entry struct {
+	GUID  string
+	Title string
+	Link  string
+	// Summary is an HTML fragment, or plain text.
+	Summary   string
+	Image     string
+	Published time.Time
+}
+
+type media struct {
+	URL  string `xml:"url,attr"`
+	Type string `xml:"type,attr"`
+	// Medium is set on media:content, e.g. "image".
+	Medium string `xml:"medium,attr"`
+}
+
+type document struct {
+	Channel struct {
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	// RSS 1.0 puts items next to the channel rather than inside it.
+	Items   []rssItem   `xml:"item"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type rssItem struct {
+	GUID        string  `xml:"guid"`
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Description string  `xml:"description"`
+	Content     string  `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	PubDate     string  `xml:"pubDate"`
+	Date        string  `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Enclosures  []media `xml:"enclosure"`
+	Media       []media `xml:"http://search.yahoo.com/mrss/ content"`
+	Thumbnails  []media `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+}
+
+type atomEntry struct {
+	ID    string `xml:"id"`
+	Title string `xml:"title"`
+	Links []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+		Type string `xml:"type,attr"`
+	} `xml:"link"`
+	Summary   atomText `xml:"summary"`
+	Content   atomText `xml:"content"`
+	Published string   `xml:"published"`
+	Updated   string   `xml:"updated"`
+}
+
+// atomText is an Atom text construct. Text and HTML content are escaped
+// character data, while XHTML content is markup, usually wrapped in a div.
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+// html returns the content as an HTML fragment, or plain text.
+func (t atomText) html() string {
+	if t.Type == "xhtml" {
+		return t.Inner
+	}
+
+	return t.Text
+}
+
+// Parse reads an RSS 0.9x/1.0/2.0 or Atom feed.
+func Parse(data []byte) ([]Entry, error) {
+	var doc document
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = charset.NewReaderLabel
+	decoder.Strict = false
+
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+
+	for _, item := range append(doc.Channel.Items, doc.Items...) {
+		entries = append(entries, Entry{
+			GUID:      strings.TrimSpace(item.GUID),
+			Title:     strings.TrimSpace(item.Title),
+			Link:      strings.TrimSpace(item.Link),
+			Summary:   firstNonEmpty(item.Content, item.Description),
+			Image:     image(slices.Concat(item.Media, item.Enclosures, item.Thumbnails)),
+			Published: parseTime(firstNonEmpty(item.PubDate, item.Date)),
+		})
+	}
+
+	for _, entry := range doc.Entries {
+		entries = append(entries, Entry{
+			GUID:      strings.TrimSpace(entry.ID),
+			Title:     strings.TrimSpace(entry.Title),
+			Link:      entry.link(),
+			Summary:   firstNonEmpty(entry.Content.html(), entry.Summary.html()),
+			Image:     entry.image(),
+			Published: parseTime(firstNonEmpty(entry.Published, entry.Updated)),
+		})
+	}
+
+	return entries, nil
+}
+
+// link prefers the rel="alternate" link, which is also the default rel.
+func (e atomEntry) link() string {
+	for _, link := range e.Links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return strings.TrimSpace(link.Href)
+		}
+	}
+
+	if len(e.Links) > 0 {
+		return strings.TrimSpace(e.Links[0].Href)
+	}
+
+	return ""
+}
+
+// image returns the first enclosure link to an image.
+func (e atomEntry) image() string {
+	for _, link := range e.Links {
+		if link.Rel == "enclosure" && strings.HasPrefix(link.Type, "image/") {
+			return strings.TrimSpace(link.Href)
+		}
+	}
+
+	return ""
+}
+
+// image returns the first media item that is an image. Thumbnails carry no
+// type and are assumed to be images.
+func image(items []media) string {
+	for _, item := range items {
+		if item.URL != "" && (item.Medium == "image" || strings.HasPrefix(item.Type, "image/") || item.Type == "" && item.Medium == "") {
+			return strings.TrimSpace(item.URL)
+		}
+	}
+
+	return ""
+}
+
+func parseTime(value string) time.Time {
+	value = strings.TrimSpace(value)
+
+	for _, format := range timeFormats {
+		if t, err := time.Parse(format, value); err == nil {
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
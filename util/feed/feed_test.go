@@ -0,0 +1,57 @@
+package feed
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAtomContent(t *testing.T) {
+	data, err := os.ReadFile("testdata/atom.xml")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := Parse(data)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		link      string
+		summary   string
+		image     string
+		published time.Time
+	}{
+		{"https://example.com/posts/hack-night", "<b>March 14</b>", "https://example.com/hack.jpg", time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)},
+		{"https://example.com/posts/newsletter", "<i>April 2</i>", "", time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC)},
+		{"https://example.com/posts/plain", "Doors open at 7.", "", time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)},
+	}
+
+	if len(entries) != len(tests) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(tests))
+	}
+
+	for i, tt := range tests {
+		entry := entries[i]
+
+		if entry.Link != tt.link {
+			t.Errorf("entry %d link = %q, want %q", i, entry.Link, tt.link)
+		}
+
+		if !strings.Contains(entry.Summary, tt.summary) {
+			t.Errorf("entry %d summary = %q, want it to contain %q", i, entry.Summary, tt.summary)
+		}
+
+		if entry.Image != tt.image {
+			t.Errorf("entry %d image = %q, want %q", i, entry.Image, tt.image)
+		}
+
+		if !entry.Published.Equal(tt.published) {
+			t.Errorf("entry %d published = %v, want %v", i, entry.Published, tt.published)
+		}
+	}
+}
@@ -0,0 +1,484 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/teambition/rrule-go"
+)
+
+const dateFormat = "20060102"
+const dateTimeFormat = "20060102T150405"
+
+var durationPattern = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// Event is a single VEVENT. For recurring events Start and End describe the
+// first instance; Expand turns them into one Event per occurrence.
+type Event struct {
+	UID          string
+	Sequence     int
+	Summary      string
+	Description  string
+	Location     string
+	Latitude     *float64
+	Longitude    *float64
+	URL          string
+	Image        string
+	Status       string
+	Categories   []string
+	Start        time.Time
+	End          time.Time
+	AllDay       bool
+	RRule        string
+	RDates       []time.Time
+	ExDates      []time.Time
+	RecurrenceID time.Time
+}
+
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Parse reads every VEVENT in an iCalendar stream. Times without a TZID or a
+// UTC marker, and all-day dates, are read in loc. Properties of components
+// nested in an event, such as a VALARM, are ignored. A property that can't be
+// read is skipped, and so is an event without a start.
+func Parse(r io.Reader, loc *time.Location) ([]Event, error) {
+	var events []Event
+	var current *Event
+	var duration string
+	// nested counts the open components inside the current event.
+	var nested int
+
+	lines, err := unfold(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for n, line := range lines {
+		prop, err := parseProperty(line)
+
+		if err != nil {
+			log.Warn().Err(err).Msgf("Skipping line %d of iCalendar stream", n+1)
+			continue
+		}
+
+		switch {
+		case current == nil:
+			if prop.name == "BEGIN" && prop.value == "VEVENT" {
+				current = &Event{}
+				duration = ""
+				nested = 0
+			}
+		case prop.name == "BEGIN":
+			nested++
+		case prop.name == "END" && nested > 0:
+			nested--
+		case prop.name == "END" && prop.value == "VEVENT":
+			if err := finish(current, duration); err != nil {
+				log.Warn().Err(err).Msgf("Skipping iCalendar event %s", current.UID)
+			} else {
+				events = append(events, *current)
+			}
+
+			current = nil
+		case nested > 0:
+		case prop.name == "DURATION":
+			duration = prop.value
+		default:
+			if err := current.set(prop, loc); err != nil {
+				log.Warn().Err(err).Msgf("Skipping line %d of iCalendar event", n+1)
+			}
+		}
+	}
+
+	return events, nil
+}
+
+func (e *Event) set(prop property, loc *time.Location) error {
+	var err error
+
+	switch prop.name {
+	case "UID":
+		e.UID = prop.value
+	case "SEQUENCE":
+		e.Sequence, err = strconv.Atoi(prop.value)
+	case "SUMMARY":
+		e.Summary = unescape(prop.value)
+	case "DESCRIPTION":
+		e.Description = unescape(prop.value)
+	case "LOCATION":
+		e.Location = unescape(prop.value)
+	case "URL":
+		e.URL = prop.value
+	case "IMAGE", "ATTACH":
+		// RFC 7986 IMAGE, or an ATTACH that links to an image.
+		isImage := prop.name == "IMAGE" || strings.HasPrefix(strings.ToLower(prop.params["FMTTYPE"]), "image/")
+
+		if isImage && e.Image == "" && prop.params["VALUE"] != "BINARY" && prop.params["ENCODING"] == "" {
+			e.Image = prop.value
+		}
+	case "GEO":
+		latitude, longitude, ok := strings.Cut(prop.value, ";")
+
+		if !ok {
+			return fmt.Errorf("GEO: expected latitude;longitude, got %q", prop.value)
+		}
+
+		lat, latErr := strconv.ParseFloat(latitude, 64)
+		lon, lonErr := strconv.ParseFloat(longitude, 64)
+
+		if latErr == nil && lonErr == nil {
+			e.Latitude, e.Longitude = &lat, &lon
+		}
+	case "STATUS":
+		e.Status = strings.ToUpper(prop.value)
+	case "CATEGORIES":
+		for _, category := range splitList(prop.value) {
+			e.Categories = append(e.Categories, unescape(category))
+		}
+	case "DTSTART":
+		e.Start, e.AllDay, err = parseTime(prop, loc)
+	case "DTEND", "DUE":
+		e.End, _, err = parseTime(prop, loc)
+	case "RRULE":
+		e.RRule = prop.value
+	case "RDATE", "EXDATE":
+		var times []time.Time
+
+		if times, err = parseTimes(prop, loc); err == nil && prop.name == "RDATE" {
+			e.RDates = append(e.RDates, times...)
+		} else if err == nil {
+			e.ExDates = append(e.ExDates, times...)
+		}
+	case "RECURRENCE-ID":
+		e.RecurrenceID, _, err = parseTime(prop, loc)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", prop.name, err)
+	}
+
+	return nil
+}
+
+// finish fills in the end of an event that only has a DURATION, or neither.
+func finish(e *Event, duration string) error {
+	if e.Start.IsZero() {
+		return fmt.Errorf("missing DTSTART")
+	}
+
+	if !e.End.IsZero() {
+		return nil
+	}
+
+	if duration != "" {
+		d, err := parseDuration(duration)
+
+		if err != nil {
+			return err
+		}
+
+		e.End = e.Start.Add(d)
+	} else if e.AllDay {
+		e.End = e.Start.AddDate(0, 0, 1)
+	} else {
+		e.End = e.Start
+	}
+
+	return nil
+}
+
+// Expand returns every occurrence that overlaps [from, until). RRULE and RDATE
+// instances are generated and EXDATEs removed, instances overridden by a
+// RECURRENCE-ID replace the generated ones, and when the same instance appears
+// more than once only the highest SEQUENCE is kept.
+func Expand(events []Event, from, until time.Time) ([]Event, error) {
+	var result []Event
+
+	masters, overrides := latest(events)
+
+	for _, master := range masters {
+		if master.RRule == "" && len(master.RDates) == 0 {
+			if overlaps(master, from, until) {
+				result = append(result, master)
+			}
+
+			continue
+		}
+
+		starts, err := occurrences(master, from, until)
+
+		if err != nil {
+			return nil, fmt.Errorf("event %s: %w", master.UID, err)
+		}
+
+		length := master.End.Sub(master.Start)
+
+		for _, start := range starts {
+			key := instanceKey(master.UID, start)
+
+			if override, ok := overrides[key]; ok {
+				delete(overrides, key)
+
+				if overlaps(override, from, until) {
+					result = append(result, override)
+				}
+
+				continue
+			}
+
+			instance := master
+			instance.Start = start
+			instance.End = start.Add(length)
+			instance.RecurrenceID = start
+			result = append(result, instance)
+		}
+	}
+
+	// Overrides left over were moved here from outside the window, or belong to
+	// a series that isn't in this feed.
+	for _, override := range overrides {
+		if overlaps(override, from, until) {
+			result = append(result, override)
+		}
+	}
+
+	slices.SortFunc(result, func(a, b Event) int {
+		return a.Start.Compare(b.Start)
+	})
+
+	return result, nil
+}
+
+// latest keeps the highest SEQUENCE of every event and instance override,
+// since updated events are often published alongside their older versions.
+func latest(events []Event) (map[string]Event, map[string]Event) {
+	masters := make(map[string]Event)
+	overrides := make(map[string]Event)
+
+	for _, event := range events {
+		target := masters
+		key := event.UID
+
+		if !event.RecurrenceID.IsZero() {
+			target = overrides
+			key = instanceKey(event.UID, event.RecurrenceID)
+		}
+
+		if existing, ok := target[key]; !ok || event.Sequence >= existing.Sequence {
+			target[key] = event
+		}
+	}
+
+	return masters, overrides
+}
+
+func occurrences(e Event, from, until time.Time) ([]time.Time, error) {
+	set := rrule.Set{}
+	set.DTStart(e.Start)
+
+	if e.RRule != "" {
+		option, err := rrule.StrToROptionInLocation(e.RRule, e.Start.Location())
+
+		if err != nil {
+			return nil, err
+		}
+
+		option.Dtstart = e.Start
+		rule, err := rrule.NewRRule(*option)
+
+		if err != nil {
+			return nil, err
+		}
+
+		set.RRule(rule)
+	}
+
+	// DTSTART is always the first instance, even if the rule doesn't match it.
+	set.RDate(e.Start)
+
+	for _, rdate := range e.RDates {
+		set.RDate(rdate)
+	}
+
+	for _, exdate := range e.ExDates {
+		set.ExDate(exdate)
+	}
+
+	// Start the search early enough to catch instances still running at from.
+	return set.Between(from.Add(-e.End.Sub(e.Start)), until, true), nil
+}
+
+func overlaps(e Event, from, until time.Time) bool {
+	return e.Start.Before(until) && (e.End.After(from) || e.Start.Equal(from))
+}
+
+func instanceKey(uid string, t time.Time) string {
+	return uid + "@" + strconv.FormatInt(t.Unix(), 10)
+}
+
+// unfold joins continuation lines, which start with a space or tab, onto the
+// line before them.
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+		} else if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, scanner.Err()
+}
+
+// parseProperty splits a content line such as
+// DTSTART;TZID=America/New_York:20240101T090000 into its parts.
+func parseProperty(line string) (property, error) {
+	prop := property{params: make(map[string]string)}
+	inQuotes := false
+	colon := -1
+
+	for i, c := range line {
+		if c == '"' {
+			inQuotes = !inQuotes
+		} else if c == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+
+	if colon == -1 {
+		return prop, fmt.Errorf("malformed line %q", line)
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	prop.name = strings.ToUpper(parts[0])
+	prop.value = line[colon+1:]
+
+	for _, param := range parts[1:] {
+		if key, value, ok := strings.Cut(param, "="); ok {
+			prop.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+
+	return prop, nil
+}
+
+func parseTime(prop property, loc *time.Location) (time.Time, bool, error) {
+	times, err := parseTimes(prop, loc)
+
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	if len(times) == 0 {
+		return time.Time{}, false, fmt.Errorf("empty value")
+	}
+
+	return times[0], isDate(prop), nil
+}
+
+// parseTimes reads a comma separated list of DATE or DATE-TIME values, in the
+// property's TZID when it names a known zone.
+func parseTimes(prop property, loc *time.Location) ([]time.Time, error) {
+	var result []time.Time
+
+	if tzid, ok := prop.params["TZID"]; ok {
+		if tz, err := time.LoadLocation(tzid); err == nil {
+			loc = tz
+		}
+	}
+
+	for _, value := range strings.Split(prop.value, ",") {
+		var t time.Time
+		var err error
+
+		value = strings.TrimSpace(value)
+
+		switch {
+		case isDate(prop) || len(value) == len(dateFormat):
+			t, err = time.ParseInLocation(dateFormat, value, loc)
+		case strings.HasSuffix(value, "Z"):
+			t, err = time.Parse(dateTimeFormat+"Z", value)
+		default:
+			t, err = time.ParseInLocation(dateTimeFormat, value, loc)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, t)
+	}
+
+	return result, nil
+}
+
+func isDate(prop property) bool {
+	return prop.params["VALUE"] == "DATE"
+}
+
+// parseDuration reads an RFC 5545 duration such as PT1H30M or P1D.
+func parseDuration(value string) (time.Duration, error) {
+	match := durationPattern.FindStringSubmatch(value)
+
+	if match == nil {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	var d time.Duration
+
+	for i, unit := range []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second} {
+		if n, err := strconv.Atoi(match[i+2]); err == nil {
+			d += time.Duration(n) * unit
+		}
+	}
+
+	if match[1] == "-" {
+		d = -d
+	}
+
+	return d, nil
+}
+
+func splitList(value string) []string {
+	var result []string
+	var current strings.Builder
+
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+1 < len(value) {
+			current.WriteByte(value[i])
+			current.WriteByte(value[i+1])
+			i++
+		} else if value[i] == ',' {
+			result = append(result, current.String())
+			current.Reset()
+		} else {
+			current.WriteByte(value[i])
+		}
+	}
+
+	return append(result, current.String())
+}
+
+func unescape(value string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(value)
+}
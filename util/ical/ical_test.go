@@ -0,0 +1,191 @@
+package ical
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func parseFixture(t *testing.T, name string, loc *time.Location) []Event {
+	t.Helper()
+
+	f, err := os.Open(filepath.Join("testdata", name))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer f.Close()
+
+	events, err := Parse(f, loc)
+
+	if err != nil {
+		t.Fatalf("Parse(%s): %v", name, err)
+	}
+
+	return events
+}
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+
+	loc, err := time.LoadLocation(name)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return loc
+}
+
+func TestParseTZID(t *testing.T) {
+	events := parseFixture(t, "tzid.ics", mustLoad(t, "America/New_York"))
+
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3", len(events))
+	}
+
+	tests := []struct {
+		uid   string
+		start time.Time
+		end   time.Time
+	}{
+		// 19:00 in Berlin summer time is 17:00 UTC.
+		{"tzid-1@example.com", time.Date(2024, 6, 10, 17, 0, 0, 0, time.UTC), time.Date(2024, 6, 10, 19, 0, 0, 0, time.UTC)},
+		{"tzid-2@example.com", time.Date(2024, 6, 10, 17, 0, 0, 0, time.UTC), time.Date(2024, 6, 10, 17, 30, 0, 0, time.UTC)},
+		// Floating times are read in the feed's configured zone.
+		{"tzid-3@example.com", time.Date(2024, 6, 10, 13, 0, 0, 0, time.UTC), time.Date(2024, 6, 10, 13, 0, 0, 0, time.UTC)},
+	}
+
+	for i, tt := range tests {
+		got := events[i]
+
+		if got.UID != tt.uid || !got.Start.Equal(tt.start) || !got.End.Equal(tt.end) {
+			t.Errorf("event %d = %s %v–%v, want %s %v–%v", i, got.UID, got.Start.UTC(), got.End.UTC(), tt.uid, tt.start, tt.end)
+		}
+	}
+
+	if name := events[0].Start.Location().String(); name != "Europe/Berlin" {
+		t.Errorf("TZID start is in %s, want Europe/Berlin", name)
+	}
+}
+
+func TestParseAllDay(t *testing.T) {
+	loc := mustLoad(t, "America/New_York")
+	events := parseFixture(t, "allday.ics", loc)
+
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+
+	tests := []struct {
+		start time.Time
+		end   time.Time
+	}{
+		// Without DTEND an all-day event lasts the day.
+		{time.Date(2024, 6, 15, 0, 0, 0, 0, loc), time.Date(2024, 6, 16, 0, 0, 0, 0, loc)},
+		{time.Date(2024, 6, 22, 0, 0, 0, 0, loc), time.Date(2024, 6, 24, 0, 0, 0, 0, loc)},
+	}
+
+	for i, tt := range tests {
+		got := events[i]
+
+		if !got.AllDay {
+			t.Errorf("event %d isn't all day", i)
+		}
+
+		if !got.Start.Equal(tt.start) || !got.End.Equal(tt.end) {
+			t.Errorf("event %d = %v–%v, want %v–%v", i, got.Start, got.End, tt.start, tt.end)
+		}
+	}
+}
+
+func TestExpandExDate(t *testing.T) {
+	loc := mustLoad(t, "America/New_York")
+	events := parseFixture(t, "exdate.ics", time.UTC)
+	from := time.Date(2024, 6, 1, 0, 0, 0, 0, loc)
+	until := time.Date(2024, 7, 1, 0, 0, 0, 0, loc)
+
+	got, err := Expand(events, from, until)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []int{3, 10, 24}
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d occurrences, want %d", len(got), len(want))
+	}
+
+	for i, day := range want {
+		start := time.Date(2024, 6, day, 18, 0, 0, 0, loc)
+
+		if !got[i].Start.Equal(start) || !got[i].End.Equal(start.Add(2*time.Hour)) {
+			t.Errorf("occurrence %d = %v–%v, want %v for two hours", i, got[i].Start, got[i].End, start)
+		}
+
+		if !got[i].RecurrenceID.Equal(start) {
+			t.Errorf("occurrence %d has RecurrenceID %v, want %v", i, got[i].RecurrenceID, start)
+		}
+	}
+}
+
+func TestExpandSequenceOverride(t *testing.T) {
+	events := parseFixture(t, "override.ics", time.UTC)
+	from := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	got, err := Expand(events, from, until)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		summary string
+		start   time.Time
+	}{
+		{"Monthly talk", time.Date(2024, 6, 5, 23, 0, 0, 0, time.UTC)},
+		{"Monthly talk (moved again)", time.Date(2024, 6, 14, 23, 0, 0, 0, time.UTC)},
+		{"Monthly talk", time.Date(2024, 6, 19, 23, 0, 0, 0, time.UTC)},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d occurrences, want %d", len(got), len(want))
+	}
+
+	for i, tt := range want {
+		if got[i].Summary != tt.summary || !got[i].Start.Equal(tt.start) {
+			t.Errorf("occurrence %d = %q at %v, want %q at %v", i, got[i].Summary, got[i].Start, tt.summary, tt.start)
+		}
+	}
+}
+
+func TestParseIgnoresAlarmsAndBadProperties(t *testing.T) {
+	events := parseFixture(t, "valarm.ics", time.UTC)
+
+	// The event without DTSTART is skipped, and the rest still parse.
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+
+	event := events[0]
+
+	if event.Description != "This month: Dune" {
+		t.Errorf("Description = %q, want the event's, not the alarm's", event.Description)
+	}
+
+	if want := time.Date(2024, 6, 10, 20, 0, 0, 0, time.UTC); !event.End.Equal(want) {
+		t.Errorf("End = %v, want %v from the event's DURATION", event.End, want)
+	}
+
+	if event.Sequence != 0 || event.Latitude != nil {
+		t.Errorf("bad SEQUENCE and GEO should be skipped, got %d and %v", event.Sequence, event.Latitude)
+	}
+
+	if events[1].UID != "after@example.com" {
+		t.Errorf("second event = %s, want after@example.com", events[1].UID)
+	}
+}
@@ -0,0 +1,138 @@
+package util
+
+import (
+	"celeve/models"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+var meetupHostPattern = regexp.MustCompile(`^(?:www\.)?meetup\.com$`)
+var meetupPathPattern = regexp.MustCompile(`^/[^/]+/events/(\d+)`)
+var eventbriteHostPattern = regexp.MustCompile(`^(?:www\.)?eventbrite\.[a-z.]+$`)
+var eventbritePathPattern = regexp.MustCompile(`^/e/(?:[^/]*-)?(\d+)/?$`)
+var lumaHostPattern = regexp.MustCompile(`^(?:www\.)?(?:lu\.ma|luma\.com)$`)
+var lumaPathPattern = regexp.MustCompile(`^/([A-Za-z0-9_-]+)/?$`)
+
+// trackingParams are query parameters that never change which page is shown.
+var trackingParams = []string{"aff", "fbclid", "gclid", "ref", "mc_cid", "mc_eid"}
+
+// CanonicalURL strips what doesn't identify a page: the fragment, tracking
+// parameters, a trailing slash and the case of the scheme and host.
+func CanonicalURL(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+
+	if err != nil || u.Host == "" {
+		return strings.TrimSpace(rawURL)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	query := u.Query()
+
+	for key := range query {
+		if strings.HasPrefix(key, "utm_") || slices.Contains(trackingParams, key) {
+			query.Del(key)
+		}
+	}
+
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+// SourceFromURL identifies an event page by the site it is on and that
+// site's own ID for the event. Pages on sites without known IDs are
+// identified by their host and canonical URL.
+func SourceFromURL(rawURL string) (source, sourceID string) {
+	canonical := CanonicalURL(rawURL)
+	u, err := url.Parse(canonical)
+
+	if err != nil || u.Host == "" {
+		return "", canonical
+	}
+
+	switch {
+	case meetupHostPattern.MatchString(u.Host):
+		if m := meetupPathPattern.FindStringSubmatch(u.Path); m != nil {
+			return "meetup", m[1]
+		}
+	case eventbriteHostPattern.MatchString(u.Host):
+		if m := eventbritePathPattern.FindStringSubmatch(u.Path); m != nil {
+			return "eventbrite", m[1]
+		}
+	case lumaHostPattern.MatchString(u.Host):
+		if m := lumaPathPattern.FindStringSubmatch(u.Path); m != nil {
+			return "luma", m[1]
+		}
+	}
+
+	return strings.TrimPrefix(u.Host, "www."), canonical
+}
+
+// GetEventID derives an event's ID from its source and the source's ID for
+// it, so it stays the same when the event is edited upstream.
+func GetEventID(source, sourceID string) string {
+	hash := sha256.Sum256([]byte(source + "\x00" + sourceID))
+
+	return hex.EncodeToString(hash[:])
+}
+
+// SetIdentity fills in an event's source, ID and content hash. Without a
+// sourceID the event can only be identified by its content.
+func SetIdentity(event *models.CalendarEvent, source, sourceID string) {
+	event.Source = source
+	event.SourceID = sourceID
+	event.ContentHash = GetEventHash(*event)
+
+	if sourceID == "" {
+		event.ID = event.ContentHash
+	} else {
+		event.ID = GetEventID(source, sourceID)
+	}
+}
+
+// FeedEntrySourceID identifies an RSS or Atom entry by its GUID, or its link
+// for feeds that leave the GUID out.
+func FeedEntrySourceID(guid, link string) string {
+	if guid = strings.TrimSpace(guid); guid != "" {
+		return guid
+	}
+
+	return CanonicalURL(link)
+}
+
+// ICSSourceID identifies one occurrence of an iCalendar event by its UID and
+// the time it was originally scheduled for.
+func ICSSourceID(uid string, occurrence time.Time) string {
+	if uid == "" {
+		return ""
+	}
+
+	return uid + "@" + occurrence.UTC().Format(time.RFC3339)
+}
+
+// FeedSource names the source of events read from a feed after the kind of
+// feed, such as "rss" or "ics", and its URL, since the IDs a feed gives its
+// entries are only unique within that feed. Events whose feed wasn't
+// recorded share a source named after the kind alone.
+func FeedSource(kind, feedURL string) string {
+	if feedURL = CanonicalURL(feedURL); feedURL == "" {
+		return kind
+	}
+
+	return kind + ":" + feedURL
+}
+
+// SetIdentityFromURL identifies an event by its OriginURL.
+func SetIdentityFromURL(event *models.CalendarEvent) {
+	source, sourceID := SourceFromURL(event.OriginURL)
+	SetIdentity(event, source, sourceID)
+}
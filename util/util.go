@@ -26,6 +26,8 @@ func GetISO3166Alpha2(keyword string) (string, error) {
 	return strings.ToLower(code.Alpha2()), nil
 }
 
+// GetEventHash hashes what an event says about itself, to tell when it has
+// changed upstream.
 func GetEventHash(event models.CalendarEvent) string {
 	sort.Strings(event.Tags)
 
@@ -4,8 +4,12 @@ export type CalendarEvent = {
 	StartTime:   string;
 	EndTime:     string;
 	Location:    string;
+	Status:      'scheduled' | 'cancelled' | 'postponed' | 'rescheduled' | 'moved-online';
 	Description: string;
 	OriginURL:   string;
+	SourceURLs:  string[] | null;
+	ImageURL:    string;
+	Thumbnail:   string;
 	Tags:        string[];
 	Processed:   boolean;
 	Relevant:    boolean;
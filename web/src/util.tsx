@@ -1,7 +1,9 @@
 import { CalendarEvent, Event } from "./models";
 
+const apiBase = "http://" + window.location.hostname + ":9898"
+
 export async function request(url: string, body?: any) {
-    url = "http://" + window.location.hostname + ":9898" + url
+    url = apiBase + url
     const headers = {
         'Content-Type': 'application/json',
     };
@@ -25,6 +27,18 @@ export async function request(url: string, body?: any) {
     }
 }
 
+export function thumbnailURL(event: CalendarEvent): string | undefined {
+    return event.Thumbnail ? apiBase + "/thumbnails/" + event.Thumbnail : undefined;
+}
+
+export function siteName(url: string): string {
+    try {
+        return new URL(url).hostname.replace(/^www\./, '');
+    } catch {
+        return url;
+    }
+}
+
 export function convertEvents(events: CalendarEvent[]): Event[] {
     return events.map((event) => ({
         id: event.ID,
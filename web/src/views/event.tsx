@@ -1,6 +1,7 @@
 import React from 'react';
 import { CalendarEvent } from '../models';
 import ReactMarkdown from 'react-markdown';
+import { siteName, thumbnailURL } from '../util';
 
 interface EventViewProps {
   event: CalendarEvent;
@@ -28,14 +29,34 @@ const EventView: React.FC<EventViewProps> = ({ event, showDescription }) => {
     return date.toLocaleString('en-US', timeOptions);
   };
 
+  const thumbnail = thumbnailURL(event);
+  const otherSources = (event.SourceURLs ?? []).filter((url) => url !== event.OriginURL);
+
   return (
     <div>
         <div key={event.ID} style={styles.eventBlock}>
+          {thumbnail && <img src={thumbnail} alt="" style={styles.thumbnail} />}
           <a href={event.OriginURL} style={styles.title} target="_blank" rel="noopener noreferrer">
             {event.Name}
           </a>
+          {event.Status && event.Status !== 'scheduled' && (
+            <span style={styles.status}>{event.Status.replace('-', ' ')}</span>
+          )}
           <div style={styles.details}>
             <div style={styles.date}>{formatDate(event.StartTime)}</div>
+            {otherSources.length > 0 && (
+              <div style={styles.sources}>
+                Also on{' '}
+                {otherSources.map((url, index) => (
+                  <React.Fragment key={url}>
+                    {index > 0 && ', '}
+                    <a href={url} style={styles.source} target="_blank" rel="noopener noreferrer">
+                      {siteName(url)}
+                    </a>
+                  </React.Fragment>
+                ))}
+              </div>
+            )}
             <div style={styles.tags}>
               {event.Tags.map((tag: string, index: number) => (
                 <span key={index} style={styles.tag}>
@@ -64,12 +85,27 @@ const styles = {
     backgroundColor: '#2d2d2d',
     cursor: 'pointer',
   },
+  thumbnail: {
+    display: 'block',
+    width: '100%',
+    maxHeight: '200px',
+    objectFit: 'cover' as const,
+    borderRadius: '3px',
+    marginBottom: '8px',
+  },
   title: {
     fontSize: '18px',
     fontWeight: 'bold',
     textDecoration: 'none',
     color: '#b8b8b8',
   },
+  status: {
+    marginLeft: '8px',
+    fontSize: '12px',
+    fontWeight: 'bold',
+    textTransform: 'uppercase' as const,
+    color: '#d9822b',
+  },
   description: {
     color: '#b8b8b8',
   },
@@ -80,6 +116,13 @@ const styles = {
     fontSize: '14px',
     color: '#666',
   },
+  sources: {
+    fontSize: '12px',
+    color: '#666',
+  },
+  source: {
+    color: '#848484',
+  },
   tags: {
     marginTop: '5px',
   },